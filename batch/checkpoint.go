@@ -0,0 +1,47 @@
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ReadCheckpoint returns the number of processed UUIDs saved in the checkpoint file at the given path.
+// A missing checkpoint file means that nothing has been processed yet.
+func ReadCheckpoint(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed reading checkpoint file: %w", err)
+	}
+
+	processed, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || processed < 0 {
+		return 0, fmt.Errorf("malformed checkpoint file %v", path)
+	}
+	return processed, nil
+}
+
+// WriteCheckpoint saves the number of processed UUIDs to the checkpoint file at the given path. The file is
+// replaced atomically, so an interruption never leaves a partially written checkpoint behind.
+func WriteCheckpoint(path string, processed int) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.WriteString(strconv.Itoa(processed) + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
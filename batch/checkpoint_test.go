@@ -0,0 +1,37 @@
+package batch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "compute.checkpoint")
+
+	processed, err := ReadCheckpoint(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, processed)
+
+	require.NoError(t, WriteCheckpoint(path, 2000))
+	require.NoError(t, WriteCheckpoint(path, 3000))
+
+	processed, err = ReadCheckpoint(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 3000, processed)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary checkpoint files should be cleaned up")
+}
+
+func TestReadMalformedCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "compute.checkpoint")
+	require.NoError(t, os.WriteFile(path, []byte("many"), 0644))
+
+	_, err := ReadCheckpoint(path)
+	assert.Error(t, err)
+}
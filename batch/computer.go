@@ -0,0 +1,176 @@
+package batch
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	log "github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/neo4j-metric-aggregator/concept"
+	tidUtils "github.com/Financial-Times/transactionid-utils-go"
+)
+
+type Format string
+
+const (
+	FormatJSONLines Format = "jsonl"
+	FormatCSV       Format = "csv"
+)
+
+// CheckpointFunc is called after every chunk is written with the total number of processed input UUIDs.
+type CheckpointFunc func(processed int) error
+
+type Computer struct {
+	metricsAggregator concept.MetricsAggregator
+	chunkSize         int
+	format            Format
+	log               *log.UPPLogger
+}
+
+func NewComputer(metricsAggregator concept.MetricsAggregator, chunkSize int, format Format, log *log.UPPLogger) (*Computer, error) {
+	if chunkSize < 1 {
+		return nil, fmt.Errorf("chunk size must be positive, got %v", chunkSize)
+	}
+	if format != FormatJSONLines && format != FormatCSV {
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+
+	return &Computer{
+		metricsAggregator: metricsAggregator,
+		chunkSize:         chunkSize,
+		format:            format,
+		log:               log,
+	}, nil
+}
+
+// Compute reads concept UUIDs, one per line, from in and writes the metrics of the concepts found in the DB to out.
+// The first skip UUIDs are not processed, so that an interrupted computation can be resumed from its last
+// checkpoint; in that case the CSV header is not written again. The context is only checked between chunks, so
+// that the chunk in progress is still written when it is cancelled. Compute returns the total number of processed
+// UUIDs, including the skipped ones.
+func (c *Computer) Compute(ctx context.Context, in io.Reader, out io.Writer, skip int, checkpoint CheckpointFunc) (int, error) {
+	tid := tidUtils.NewTransactionID()
+	// the queries must not be cancelled with ctx, otherwise the chunk in progress would be lost
+	queryCtx := tidUtils.TransactionAwareContext(context.WithoutCancel(ctx), tid)
+	logCompute := c.log.WithField(tidUtils.TransactionIDKey, tid)
+
+	w := bufio.NewWriter(out)
+	write := c.writeJSONLines
+	if c.format == FormatCSV {
+		write = c.writeCSV
+		if skip == 0 {
+			if err := writeCSVRecords(w, [][]string{concept.ConceptCSVHeader}); err != nil {
+				return 0, fmt.Errorf("failed writing csv header: %w", err)
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(in)
+	processed := 0
+	found := 0
+	start := time.Now()
+	chunk := make([]string, 0, c.chunkSize)
+
+	flushChunk := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		concepts, err := c.metricsAggregator.GetConceptMetrics(queryCtx, chunk)
+		if err != nil {
+			return err
+		}
+		if err = write(w, concepts); err != nil {
+			return fmt.Errorf("failed writing output: %w", err)
+		}
+		if err = w.Flush(); err != nil {
+			return fmt.Errorf("failed writing output: %w", err)
+		}
+
+		processed += len(chunk)
+		found += len(concepts)
+		chunk = chunk[:0]
+
+		if checkpoint != nil {
+			if err = checkpoint(processed); err != nil {
+				return fmt.Errorf("failed saving checkpoint: %w", err)
+			}
+		}
+
+		logCompute.
+			WithField("processed", processed).
+			WithField("found", found).
+			WithField("elapsed", time.Since(start).Round(time.Second).String()).
+			Info("computed metrics for concept chunk")
+		return nil
+	}
+
+	for scanner.Scan() {
+		conceptUUID := strings.TrimSpace(scanner.Text())
+		if conceptUUID == "" {
+			continue
+		}
+		if processed < skip {
+			processed++
+			continue
+		}
+
+		chunk = append(chunk, conceptUUID)
+		if len(chunk) < c.chunkSize {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			logCompute.WithField("processed", processed).Warn("computation interrupted")
+			return processed, err
+		}
+		if err := flushChunk(); err != nil {
+			return processed, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return processed, fmt.Errorf("failed reading concept UUIDs: %w", err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		logCompute.WithField("processed", processed).Warn("computation interrupted")
+		return processed, err
+	}
+	if err := flushChunk(); err != nil {
+		return processed, err
+	}
+
+	logCompute.
+		WithField("processed", processed).
+		WithField("found", found).
+		WithField("elapsed", time.Since(start).Round(time.Second).String()).
+		Info("finished computing metrics")
+	return processed, nil
+}
+
+func (c *Computer) writeJSONLines(w io.Writer, concepts []concept.Concept) error {
+	enc := json.NewEncoder(w)
+	for _, cpt := range concepts {
+		if err := enc.Encode(&cpt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Computer) writeCSV(w io.Writer, concepts []concept.Concept) error {
+	records := make([][]string, 0, len(concepts))
+	for _, cpt := range concepts {
+		records = append(records, cpt.CSVRecord())
+	}
+	return writeCSVRecords(w, records)
+}
+
+func writeCSVRecords(w io.Writer, records [][]string) error {
+	cw := csv.NewWriter(w)
+	return cw.WriteAll(records)
+}
@@ -0,0 +1,207 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	logger "github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/neo4j-metric-aggregator/concept"
+)
+
+const testInput = `
+38ea6443-050e-4d02-9564-537490f84abd
+a4de0e8f-96f4-4ccf-ba26-410f005e021b
+
+e25c0e2c-e275-403b-8fd8-9f079634cae9
+`
+
+var testConcepts = map[string]concept.Concept{
+	"38ea6443-050e-4d02-9564-537490f84abd": {
+		UUID:    "38ea6443-050e-4d02-9564-537490f84abd",
+		Metrics: concept.Metrics{AnnotationsCount: 1, PrevWeekAnnotationsCount: 2},
+	},
+	"e25c0e2c-e275-403b-8fd8-9f079634cae9": {
+		UUID:    "e25c0e2c-e275-403b-8fd8-9f079634cae9",
+		Metrics: concept.Metrics{AnnotationsCount: 12, PrevWeekAnnotationsCount: 0},
+	},
+}
+
+func TestComputeJSONLines(t *testing.T) {
+	ma := newTestMetricsAggregator()
+	ma.On("GetConceptMetrics", mock.Anything, []string{"38ea6443-050e-4d02-9564-537490f84abd", "a4de0e8f-96f4-4ccf-ba26-410f005e021b"})
+	ma.On("GetConceptMetrics", mock.Anything, []string{"e25c0e2c-e275-403b-8fd8-9f079634cae9"})
+
+	c, err := NewComputer(ma, 2, FormatJSONLines, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	require.NoError(t, err)
+
+	var checkpoints []int
+	out := &bytes.Buffer{}
+	processed, err := c.Compute(context.Background(), strings.NewReader(testInput), out, 0, func(processed int) error {
+		checkpoints = append(checkpoints, processed)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, processed)
+	assert.Equal(t, []int{2, 3}, checkpoints)
+	assert.Equal(t,
+		`{"uuid":"38ea6443-050e-4d02-9564-537490f84abd","metrics":{"annotationsCount":1,"prevWeekAnnotationsCount":2,"firstAnnotatedAt":null,"lastAnnotatedAt":null}}`+"\n"+
+			`{"uuid":"e25c0e2c-e275-403b-8fd8-9f079634cae9","metrics":{"annotationsCount":12,"prevWeekAnnotationsCount":0,"firstAnnotatedAt":null,"lastAnnotatedAt":null}}`+"\n",
+		out.String())
+	ma.AssertExpectations(t)
+}
+
+func TestComputeCSV(t *testing.T) {
+	ma := newTestMetricsAggregator()
+	ma.On("GetConceptMetrics", mock.Anything, []string{"38ea6443-050e-4d02-9564-537490f84abd", "a4de0e8f-96f4-4ccf-ba26-410f005e021b", "e25c0e2c-e275-403b-8fd8-9f079634cae9"})
+
+	c, err := NewComputer(ma, 10, FormatCSV, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	processed, err := c.Compute(context.Background(), strings.NewReader(testInput), out, 0, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, processed)
+	assert.Equal(t, "uuid,annotationsCount,prevWeekAnnotationsCount,firstAnnotatedAt,lastAnnotatedAt\n"+
+		"38ea6443-050e-4d02-9564-537490f84abd,1,2,,\n"+
+		"e25c0e2c-e275-403b-8fd8-9f079634cae9,12,0,,\n", out.String())
+	ma.AssertExpectations(t)
+}
+
+func TestComputeResumeFromCheckpoint(t *testing.T) {
+	ma := newTestMetricsAggregator()
+	ma.On("GetConceptMetrics", mock.Anything, []string{"e25c0e2c-e275-403b-8fd8-9f079634cae9"})
+
+	c, err := NewComputer(ma, 2, FormatCSV, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	require.NoError(t, err)
+
+	var checkpoints []int
+	out := &bytes.Buffer{}
+	processed, err := c.Compute(context.Background(), strings.NewReader(testInput), out, 2, func(processed int) error {
+		checkpoints = append(checkpoints, processed)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, processed)
+	assert.Equal(t, []int{3}, checkpoints)
+	assert.Equal(t, "e25c0e2c-e275-403b-8fd8-9f079634cae9,12,0,,\n", out.String())
+	ma.AssertExpectations(t)
+}
+
+func TestComputeInterrupted(t *testing.T) {
+	ma := newTestMetricsAggregator()
+
+	c, err := NewComputer(ma, 2, FormatJSONLines, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	out := &bytes.Buffer{}
+	processed, err := c.Compute(ctx, strings.NewReader(testInput), out, 0, nil)
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 0, processed)
+	assert.Empty(t, out.String())
+	ma.AssertExpectations(t)
+}
+
+func TestComputeInterruptedMidChunk(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ma := newTestMetricsAggregator()
+	ma.On("GetConceptMetrics", mock.Anything, []string{"38ea6443-050e-4d02-9564-537490f84abd", "a4de0e8f-96f4-4ccf-ba26-410f005e021b"}).
+		Run(func(args mock.Arguments) {
+			cancel()
+			assert.NoError(t, args.Get(0).(context.Context).Err(), "the chunk in progress must not be cancelled")
+		})
+
+	c, err := NewComputer(ma, 2, FormatJSONLines, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	require.NoError(t, err)
+
+	var checkpoints []int
+	out := &bytes.Buffer{}
+	processed, err := c.Compute(ctx, strings.NewReader(testInput), out, 0, func(processed int) error {
+		checkpoints = append(checkpoints, processed)
+		return nil
+	})
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 2, processed)
+	assert.Equal(t, []int{2}, checkpoints)
+	assert.Equal(t, `{"uuid":"38ea6443-050e-4d02-9564-537490f84abd","metrics":{"annotationsCount":1,"prevWeekAnnotationsCount":2,"firstAnnotatedAt":null,"lastAnnotatedAt":null}}`+"\n", out.String())
+	ma.AssertExpectations(t)
+}
+
+func TestComputeMetricsAggregatorError(t *testing.T) {
+	ma := new(MockMetricsAggregator)
+	ma.On("GetConceptMetrics", mock.Anything, mock.Anything).Return([]concept.Concept{}, errors.New("computer says no"))
+
+	c, err := NewComputer(ma, 2, FormatJSONLines, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	require.NoError(t, err)
+
+	var checkpoints []int
+	processed, err := c.Compute(context.Background(), strings.NewReader(testInput), &bytes.Buffer{}, 0, func(processed int) error {
+		checkpoints = append(checkpoints, processed)
+		return nil
+	})
+
+	assert.EqualError(t, err, "computer says no")
+	assert.Equal(t, 0, processed)
+	assert.Empty(t, checkpoints)
+}
+
+func TestNewComputerInvalidOptions(t *testing.T) {
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	_, err := NewComputer(new(MockMetricsAggregator), 0, FormatCSV, log)
+	assert.EqualError(t, err, "chunk size must be positive, got 0")
+
+	_, err = NewComputer(new(MockMetricsAggregator), 10, Format("xml"), log)
+	assert.EqualError(t, err, `unknown output format "xml"`)
+}
+
+type MockMetricsAggregator struct {
+	mock.Mock
+	results map[string]concept.Concept
+}
+
+// newTestMetricsAggregator returns a mock which answers with the testConcepts found among the requested UUIDs.
+func newTestMetricsAggregator() *MockMetricsAggregator {
+	return &MockMetricsAggregator{results: testConcepts}
+}
+
+func (m *MockMetricsAggregator) GetConceptMetrics(ctx context.Context, conceptUUIDs []string) ([]concept.Concept, error) {
+	args := m.Called(ctx, conceptUUIDs)
+	if m.results == nil {
+		return args.Get(0).([]concept.Concept), args.Error(1)
+	}
+
+	concepts := []concept.Concept{}
+	for _, conceptUUID := range conceptUUIDs {
+		if c, ok := m.results[conceptUUID]; ok {
+			concepts = append(concepts, c)
+		}
+	}
+	return concepts, nil
+}
+
+func (m *MockMetricsAggregator) GetSummary(ctx context.Context) (concept.Summary, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(concept.Summary), args.Error(1)
+}
+
+func (m *MockMetricsAggregator) GetOrphans(ctx context.Context, kind concept.OrphanKind, since int64, offset int, limit int) (concept.OrphansReport, error) {
+	args := m.Called(ctx, kind, since, offset, limit)
+	return args.Get(0).(concept.OrphansReport), args.Error(1)
+}
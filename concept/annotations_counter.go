@@ -1,48 +1,61 @@
 package concept
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
 
-	cmneo4j "github.com/Financial-Times/cm-neo4j-driver"
+	log "github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
+	tidUtils "github.com/Financial-Times/transactionid-utils-go"
 )
 
 const countAnnotationsQuery = `
 	OPTIONAL MATCH (canonicalConcept:Concept{prefUUID:$uuid})<-[:EQUIVALENT_TO]-(source:Concept)
 	OPTIONAL MATCH (source)<-[]-(content:Content)
-	WITH canonicalConcept, count(DISTINCT(content)) AS totalCount, COLLECT(DISTINCT(content)) as contentList
-	WITH canonicalConcept, totalCount, [x in contentList where x.publishedDateEpoch > $since] as recent
-	RETURN CASE canonicalConcept WHEN NULL THEN '' ELSE canonicalConcept.prefUUID END AS uuid, size(recent) AS recentCount, totalCount
+	WITH canonicalConcept, count(DISTINCT(content)) AS totalCount, COLLECT(DISTINCT(content)) as contentList,
+		min(content.publishedDateEpoch) AS firstPublishedDateEpoch, max(content.publishedDateEpoch) AS lastPublishedDateEpoch
+	WITH canonicalConcept, totalCount, firstPublishedDateEpoch, lastPublishedDateEpoch, [x in contentList where x.publishedDateEpoch > $since] as recent
+	RETURN CASE canonicalConcept WHEN NULL THEN '' ELSE canonicalConcept.prefUUID END AS uuid, size(recent) AS recentCount, totalCount,
+		firstPublishedDateEpoch, lastPublishedDateEpoch
 `
 
 type AnnotationsCounter interface {
-	Count(conceptUUIDs []string) (map[string]Metrics, error)
+	Count(ctx context.Context, conceptUUIDs []string) (map[string]Metrics, error)
 }
 
-func NewAnnotationsCounter(driver *cmneo4j.Driver) AnnotationsCounter {
-	return &neoAnnotationsCounter{driver}
+// NewAnnotationsCounter creates a counter querying Neo4j for the annotations of every concept, logging the
+// queries which take longer than slowQueryThreshold. The slow queries are not logged when it is zero.
+func NewAnnotationsCounter(driver *neo.Driver, slowQueryThreshold time.Duration, log *log.UPPLogger) AnnotationsCounter {
+	return &neoAnnotationsCounter{driver: driver, slowQueryThreshold: slowQueryThreshold, log: log}
 }
 
 type neoAnnotationsCounter struct {
-	driver *cmneo4j.Driver
+	driver             *neo.Driver
+	slowQueryThreshold time.Duration
+	log                *log.UPPLogger
 }
 
 // Count returns metrics for the given concept uuids list. If given uuid is not found in the db, it is skipped
-// from the result map.
-func (c *neoAnnotationsCounter) Count(conceptUUIDs []string) (map[string]Metrics, error) {
+// from the result map. The queries are profiled when the context is returned by WithProfiling.
+func (c *neoAnnotationsCounter) Count(ctx context.Context, conceptUUIDs []string) (map[string]Metrics, error) {
 	retval := make(map[string]Metrics)
-	queries := buildQueries(conceptUUIDs)
+	queries := buildQueries(conceptUUIDs, isProfiling(ctx))
 
-	err := c.driver.Read(queries...)
-	if errors.Is(err, cmneo4j.ErrNoResultsFound) {
-		// The defined query uses OPTIONAL MATCH-es and shouldn't return cmneo4j.ErrNoResultsFound,
+	start := time.Now()
+	err := c.driver.Read(ctx, queries...)
+	observeQuery(ctx, queryCountAnnotations, start)
+	if errors.Is(err, neo.ErrNoResultsFound) {
+		// The defined query uses OPTIONAL MATCH-es and shouldn't return neo.ErrNoResultsFound,
 		// unexpected error happen.
 		return nil, fmt.Errorf("unexpected 'no result' returned from the DB: %w", err)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed executing queries: %w", err)
+		return nil, queryError(err)
 	}
+	c.logSlowQueries(ctx, conceptUUIDs, queries)
+	addProfiles(ctx, queryProfiles(conceptUUIDs, queries))
 
 	for _, q := range queries {
 		neoRes := q.Result
@@ -53,24 +66,73 @@ func (c *neoAnnotationsCounter) Count(conceptUUIDs []string) (map[string]Metrics
 		if res.UUID == "" {
 			continue
 		}
-		retval[res.UUID] = Metrics{PrevWeekAnnotationsCount: res.RecentCount, AnnotationsCount: res.TotalCount}
+		retval[res.UUID] = Metrics{
+			PrevWeekAnnotationsCount: res.RecentCount,
+			AnnotationsCount:         res.TotalCount,
+			FirstAnnotatedAt:         epochToRFC3339(res.FirstPublishedDateEpoch),
+			LastAnnotatedAt:          epochToRFC3339(res.LastPublishedDateEpoch),
+		}
 	}
 
 	return retval, nil
 }
 
-func buildQueries(conceptUUIDs []string) []*cmneo4j.Query {
-	var queries []*cmneo4j.Query
+// epochToRFC3339 formats the given unix epoch as an RFC3339 UTC timestamp. A nil epoch, which is what the DB
+// returns for concepts without annotations, results in a nil timestamp.
+func epochToRFC3339(epoch *int64) *string {
+	if epoch == nil {
+		return nil
+	}
+	t := time.Unix(*epoch, 0).UTC().Format(time.RFC3339)
+	return &t
+}
+
+func (c *neoAnnotationsCounter) logSlowQueries(ctx context.Context, conceptUUIDs []string, queries []*neo.Query) {
+	if c.slowQueryThreshold <= 0 || isHealthCheck(ctx) {
+		return
+	}
+	for i, q := range queries {
+		if q.Duration < c.slowQueryThreshold {
+			continue
+		}
+		c.log.WithField(tidUtils.TransactionIDKey, ctx.Value(tidUtils.TransactionIDKey)).
+			WithField("uuid", conceptUUIDs[i]).
+			WithField("duration", q.Duration.String()).
+			WithField("batchSize", len(conceptUUIDs)).
+			Warn("slow annotations count query")
+	}
+}
+
+// queryProfiles returns the profiles of the executed queries, built with buildQueries for the given concepts.
+func queryProfiles(conceptUUIDs []string, queries []*neo.Query) []QueryProfile {
+	var profiles []QueryProfile
+	for i, q := range queries {
+		if q.ProfileSummary == nil {
+			continue
+		}
+		profiles = append(profiles, QueryProfile{
+			UUID:       conceptUUIDs[i],
+			DurationMs: float64(q.Duration) / float64(time.Millisecond),
+			DBHits:     q.ProfileSummary.DBHits,
+			Rows:       q.ProfileSummary.Rows,
+		})
+	}
+	return profiles
+}
+
+func buildQueries(conceptUUIDs []string, profile bool) []*neo.Query {
+	var queries []*neo.Query
 
 	now := time.Now().Unix()
 	weekAgo := now - 7*24*3600
 
 	for _, conceptUUID := range conceptUUIDs {
 		res := NeoMetricResult{}
-		q := &cmneo4j.Query{
-			Cypher: countAnnotationsQuery,
-			Params: map[string]interface{}{"uuid": conceptUUID, "since": weekAgo},
-			Result: &res,
+		q := &neo.Query{
+			Cypher:  countAnnotationsQuery,
+			Params:  map[string]interface{}{"uuid": conceptUUID, "since": weekAgo},
+			Result:  &res,
+			Profile: profile,
 		}
 		queries = append(queries, q)
 	}
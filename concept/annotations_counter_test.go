@@ -3,6 +3,7 @@
 package concept
 
 import (
+	"context"
 	"math/rand"
 	"os"
 	"testing"
@@ -13,23 +14,24 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
-	cmneo4j "github.com/Financial-Times/cm-neo4j-driver"
 	"github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
 )
 
 type AnnotationsCounterTestSuite struct {
 	suite.Suite
-	driver *cmneo4j.Driver
+	driver *neo.Driver
+	log    *logger.UPPLogger
 }
 
 func TestNewAnnotationsCounterConnectionError(t *testing.T) {
 	log := logger.NewUPPLogger("test-neo4j-metric-aggregator", "warning")
-	driver, err := cmneo4j.NewDefaultDriver("bolt://localhost:80", log)
+	driver, err := neo.NewDriver("bolt://localhost:80", log, neo.DefaultConfig())
 	require.NoError(t, err)
 
-	ac := NewAnnotationsCounter(driver)
+	ac := NewAnnotationsCounter(driver, 0, log)
 
-	_, err = ac.Count([]string{uuid.New().String()})
+	_, err = ac.Count(context.Background(), []string{uuid.New().String()})
 	assert.Error(t, err)
 }
 
@@ -41,9 +43,10 @@ func (suite *AnnotationsCounterTestSuite) SetupTest() {
 	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
 	neoTestURL := getNeoTestURL(suite.T())
 
-	d, err := cmneo4j.NewDefaultDriver(neoTestURL, log)
+	d, err := neo.NewDriver(neoTestURL, log, neo.DefaultConfig())
 	require.NoError(suite.T(), err)
 	suite.driver = d
+	suite.log = log
 }
 
 func (suite *AnnotationsCounterTestSuite) TearDownTest() {
@@ -56,14 +59,24 @@ func (suite *AnnotationsCounterTestSuite) TestCountSingleValue() {
 	expectedRecentAnnotationsCount := 22
 	suite.writeTestConceptWithAnnotations(conceptUUID, 3, expectedAnnotationsCount, expectedRecentAnnotationsCount)
 
-	ac := NewAnnotationsCounter(suite.driver)
-	counts, err := ac.Count([]string{conceptUUID})
+	ac := NewAnnotationsCounter(suite.driver, 0, suite.log)
+	counts, err := ac.Count(context.Background(), []string{conceptUUID})
 
 	assert.NoError(suite.T(), err)
 	assert.Len(suite.T(), counts, 1)
 
 	assert.Equal(suite.T(), int64(expectedRecentAnnotationsCount), counts[conceptUUID].PrevWeekAnnotationsCount)
 	assert.Equal(suite.T(), int64(expectedAnnotationsCount), counts[conceptUUID].AnnotationsCount)
+
+	// The non-recent annotations are written a week and a day ago, the recent ones now.
+	require.NotNil(suite.T(), counts[conceptUUID].FirstAnnotatedAt)
+	require.NotNil(suite.T(), counts[conceptUUID].LastAnnotatedAt)
+	firstAnnotatedAt, err := time.Parse(time.RFC3339, *counts[conceptUUID].FirstAnnotatedAt)
+	require.NoError(suite.T(), err)
+	lastAnnotatedAt, err := time.Parse(time.RFC3339, *counts[conceptUUID].LastAnnotatedAt)
+	require.NoError(suite.T(), err)
+	assert.WithinDuration(suite.T(), time.Now().Add(-8*24*time.Hour), firstAnnotatedAt, time.Minute)
+	assert.WithinDuration(suite.T(), time.Now(), lastAnnotatedAt, time.Minute)
 }
 
 func (suite *AnnotationsCounterTestSuite) TestCountMultiValue() {
@@ -95,8 +108,8 @@ func (suite *AnnotationsCounterTestSuite) TestCountMultiValue() {
 		conceptUUID4,
 	}
 
-	ac := NewAnnotationsCounter(suite.driver)
-	counts, err := ac.Count(uuids)
+	ac := NewAnnotationsCounter(suite.driver, 0, suite.log)
+	counts, err := ac.Count(context.Background(), uuids)
 	assert.NoError(suite.T(), err)
 	assert.Len(suite.T(), counts, 4)
 	assert.Equal(suite.T(), int64(expectedAnnotationsCount1), counts[conceptUUID1].AnnotationsCount)
@@ -107,6 +120,30 @@ func (suite *AnnotationsCounterTestSuite) TestCountMultiValue() {
 	assert.Equal(suite.T(), int64(expectedPrevAnnotationsCount3), counts[conceptUUID3].PrevWeekAnnotationsCount)
 	assert.Equal(suite.T(), int64(expectedAnnotationsCount4), counts[conceptUUID4].AnnotationsCount)
 	assert.Equal(suite.T(), int64(expectedPrevAnnotationsCount4), counts[conceptUUID4].PrevWeekAnnotationsCount)
+	assert.Nil(suite.T(), counts[conceptUUID4].FirstAnnotatedAt)
+	assert.Nil(suite.T(), counts[conceptUUID4].LastAnnotatedAt)
+}
+
+func (suite *AnnotationsCounterTestSuite) TestCountProfiled() {
+	conceptUUID := uuid.New().String()
+	suite.writeTestConceptWithAnnotations(conceptUUID, 2, 5, 1)
+	missingUUID := uuid.New().String()
+
+	ctx := WithProfiling(context.Background())
+	ac := NewAnnotationsCounter(suite.driver, 0, suite.log)
+	counts, err := ac.Count(ctx, []string{conceptUUID, missingUUID})
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), counts, 1)
+
+	profiles := Profiles(ctx)
+	require.Len(suite.T(), profiles, 2)
+	assert.Equal(suite.T(), conceptUUID, profiles[0].UUID)
+	assert.Equal(suite.T(), missingUUID, profiles[1].UUID)
+	for _, p := range profiles {
+		assert.Equal(suite.T(), int64(1), p.Rows)
+		assert.Greater(suite.T(), p.DBHits, int64(0))
+		assert.Greater(suite.T(), p.DurationMs, float64(0))
+	}
 }
 
 func (suite *AnnotationsCounterTestSuite) TestCountWithMissingConcepts() {
@@ -130,8 +167,8 @@ func (suite *AnnotationsCounterTestSuite) TestCountWithMissingConcepts() {
 		conceptUUID4,
 	}
 
-	ac := NewAnnotationsCounter(suite.driver)
-	counts, err := ac.Count(uuids)
+	ac := NewAnnotationsCounter(suite.driver, 0, suite.log)
+	counts, err := ac.Count(context.Background(), uuids)
 	assert.NoError(suite.T(), err)
 	assert.Len(suite.T(), counts, 2)
 	assert.Equal(suite.T(), int64(expectedAnnCount1), counts[conceptUUID1].AnnotationsCount)
@@ -156,8 +193,8 @@ func (suite *AnnotationsCounterTestSuite) TestCountWithNoRecentAnnotations() {
 		conceptUUID2,
 	}
 
-	ac := NewAnnotationsCounter(suite.driver)
-	counts, err := ac.Count(uuids)
+	ac := NewAnnotationsCounter(suite.driver, 0, suite.log)
+	counts, err := ac.Count(context.Background(), uuids)
 	assert.NoError(suite.T(), err)
 	assert.Len(suite.T(), counts, 2)
 	assert.Equal(suite.T(), int64(expectedAnnCount1), counts[conceptUUID1].AnnotationsCount)
@@ -182,7 +219,7 @@ func getNeoTestURL(t *testing.T) string {
 
 func (suite *AnnotationsCounterTestSuite) writeTestConceptWithAnnotations(conceptPrefUUID string, equivalentConcepts, totalAnnCount, recentAnnCount int) {
 	// Create canonical concept node.
-	canonicalQ := &cmneo4j.Query{
+	canonicalQ := &neo.Query{
 		Cypher: "CREATE (n:Concept{prefUUID: $prefUUID})",
 		Params: map[string]interface{}{"prefUUID": conceptPrefUUID},
 	}
@@ -194,7 +231,7 @@ func (suite *AnnotationsCounterTestSuite) writeTestConceptWithAnnotations(concep
 		// create equivalent node
 		equivalentConceptUUID := uuid.New().String()
 
-		sourceQ := &cmneo4j.Query{
+		sourceQ := &neo.Query{
 			Cypher: "MATCH (n:Concept{prefUUID: $prefUUID}) CREATE (n)<-[:EQUIVALENT_TO]-(x:Concept{uuid:$uuid})",
 			Params: map[string]interface{}{"prefUUID": conceptPrefUUID, "uuid": equivalentConceptUUID},
 		}
@@ -221,7 +258,7 @@ func (suite *AnnotationsCounterTestSuite) writeTestConceptWithAnnotations(concep
 			pubDate = pubDate - 7*24*3600 - 24*3600
 		}
 
-		contentQ := &cmneo4j.Query{
+		contentQ := &neo.Query{
 			Cypher: "MATCH (n:Concept{uuid: $uuid}) CREATE (n)<-[:REL]-(c:Content{publishedDateEpoch: $pubDate})",
 			Params: map[string]interface{}{"uuid": source, "pubDate": pubDate},
 		}
@@ -234,9 +271,9 @@ func (suite *AnnotationsCounterTestSuite) writeTestConceptWithAnnotations(concep
 
 func (suite *AnnotationsCounterTestSuite) cleanDB() {
 	//delete content
-	err := suite.driver.Write(&cmneo4j.Query{Cypher: "MATCH (n:Content) DETACH DELETE n"})
+	err := suite.driver.Write(&neo.Query{Cypher: "MATCH (n:Content) DETACH DELETE n"})
 	require.NoError(suite.T(), err)
 	//delete concepts
-	err = suite.driver.Write(&cmneo4j.Query{Cypher: "MATCH (n:Concept) DETACH DELETE n"})
+	err = suite.driver.Write(&neo.Query{Cypher: "MATCH (n:Concept) DETACH DELETE n"})
 	require.NoError(suite.T(), err)
 }
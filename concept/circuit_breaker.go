@@ -0,0 +1,179 @@
+package concept
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/Financial-Times/go-logger/v2"
+)
+
+type CircuitState int
+
+const (
+	// CircuitClosed lets all the calls through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects all the calls until the open timeout elapses.
+	CircuitOpen
+	// CircuitHalfOpen lets a single trial call through, which closes the circuit when it succeeds and opens it
+	// again when it fails.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitOpenError is returned instead of calling Neo4j while the circuit is open. It is an ErrDatabaseUnavailable
+// error.
+type CircuitOpenError struct {
+	// RetryAfter is the time left until the circuit half-opens.
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return "circuit breaker is open, Neo4j is not queried until it recovers"
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrDatabaseUnavailable
+}
+
+// CircuitBreaker stops querying Neo4j after failureThreshold consecutive calls failed because it is unavailable
+// or timed out, and lets a trial call through every openTimeout to check whether it has recovered.
+type CircuitBreaker struct {
+	failureThreshold int
+	openTimeout      time.Duration
+	log              *log.UPPLogger
+	now              func() time.Time
+
+	mu            sync.Mutex
+	state         CircuitState
+	failures      int
+	openedAt      time.Time
+	trialInFlight bool
+}
+
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration, log *log.UPPLogger) *CircuitBreaker {
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		log:              log,
+		now:              time.Now,
+	}
+}
+
+// State returns the state of the circuit. An open circuit is reported as half-open once the open timeout has
+// elapsed, even before the trial call is made.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && !b.now().Before(b.halfOpensAt()) {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// Execute calls fn unless the circuit is open, in which case a *CircuitOpenError is returned.
+func (b *CircuitBreaker) Execute(fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := fn()
+	b.record(err)
+	return err
+}
+
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case CircuitOpen:
+		if now.Before(b.halfOpensAt()) {
+			return &CircuitOpenError{RetryAfter: b.halfOpensAt().Sub(now)}
+		}
+		b.state = CircuitHalfOpen
+		b.trialInFlight = true
+		b.log.Info("circuit breaker is half-open, trying Neo4j")
+	case CircuitHalfOpen:
+		if b.trialInFlight {
+			return &CircuitOpenError{RetryAfter: b.openTimeout}
+		}
+		b.trialInFlight = true
+	}
+	return nil
+}
+
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// a call running out of its request budget says nothing about the health of Neo4j, the requests of a few
+	// clients asking for heavy concepts must not open the circuit for everyone
+	if errors.Is(err, ErrTimeout) {
+		b.trialInFlight = false
+		return
+	}
+
+	if !isCircuitFailure(err) {
+		if b.state != CircuitClosed {
+			b.log.Info("circuit breaker is closed, Neo4j has recovered")
+		}
+		b.state = CircuitClosed
+		b.failures = 0
+		b.trialInFlight = false
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.failureThreshold {
+		b.log.WithError(err).
+			WithField("failures", b.failures).
+			Warnf("circuit breaker is open, Neo4j is not queried for %v", b.openTimeout)
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+		b.trialInFlight = false
+	}
+}
+
+func (b *CircuitBreaker) halfOpensAt() time.Time {
+	return b.openedAt.Add(b.openTimeout)
+}
+
+// isCircuitFailure reports whether err means that Neo4j is unreachable or failing. Any other outcome of a call,
+// including other errors, shows that Neo4j is responding.
+func isCircuitFailure(err error) bool {
+	return errors.Is(err, ErrDatabaseUnavailable)
+}
+
+// NewCircuitBreakerAnnotationsCounter wraps counter with the given circuit breaker.
+func NewCircuitBreakerAnnotationsCounter(counter AnnotationsCounter, breaker *CircuitBreaker) AnnotationsCounter {
+	return &circuitBreakerAnnotationsCounter{counter: counter, breaker: breaker}
+}
+
+type circuitBreakerAnnotationsCounter struct {
+	counter AnnotationsCounter
+	breaker *CircuitBreaker
+}
+
+func (c *circuitBreakerAnnotationsCounter) Count(ctx context.Context, conceptUUIDs []string) (map[string]Metrics, error) {
+	var metrics map[string]Metrics
+	err := c.breaker.Execute(func() error {
+		var err error
+		metrics, err = c.counter.Count(ctx, conceptUUIDs)
+		return err
+	})
+	return metrics, err
+}
@@ -0,0 +1,137 @@
+package concept
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	logger "github.com/Financial-Times/go-logger/v2"
+)
+
+var errNeo4jDown = WithKind(ErrDatabaseUnavailable, errors.New("failed executing queries: connection refused"))
+
+func newTestCircuitBreaker(failureThreshold int, openTimeout time.Duration) (*CircuitBreaker, *time.Time) {
+	now := time.Date(2021, 10, 18, 9, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker(failureThreshold, openTimeout, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestCircuitBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	b, _ := newTestCircuitBreaker(3, time.Minute)
+	calls := 0
+	fail := func() error {
+		calls++
+		return errNeo4jDown
+	}
+
+	assert.Equal(t, errNeo4jDown, b.Execute(fail))
+	assert.Equal(t, errNeo4jDown, b.Execute(fail))
+	assert.Equal(t, CircuitClosed, b.State())
+	assert.Equal(t, errNeo4jDown, b.Execute(fail))
+	assert.Equal(t, CircuitOpen, b.State())
+
+	err := b.Execute(fail)
+	var openErr *CircuitOpenError
+	require.True(t, errors.As(err, &openErr))
+	assert.Equal(t, time.Minute, openErr.RetryAfter)
+	assert.True(t, errors.Is(err, ErrDatabaseUnavailable))
+	assert.Equal(t, 3, calls)
+}
+
+func TestCircuitBreakerIgnoresOtherErrors(t *testing.T) {
+	b, _ := newTestCircuitBreaker(2, time.Minute)
+
+	assert.Error(t, b.Execute(func() error { return errNeo4jDown }))
+	assert.Error(t, b.Execute(func() error { return errors.New("Neo4jError: Neo.ClientError.Statement.SyntaxError") }))
+	assert.Error(t, b.Execute(func() error { return errNeo4jDown }))
+	assert.Equal(t, CircuitClosed, b.State())
+
+	assert.Error(t, b.Execute(func() error { return errNeo4jDown }))
+	assert.Equal(t, CircuitOpen, b.State())
+}
+
+func TestCircuitBreakerIgnoresTimeouts(t *testing.T) {
+	b, now := newTestCircuitBreaker(2, time.Minute)
+	timeout := func() error { return WithKind(ErrTimeout, errors.New("neo4j transaction timed out")) }
+
+	for i := 0; i < 5; i++ {
+		assert.Error(t, b.Execute(timeout))
+	}
+	assert.Equal(t, CircuitClosed, b.State())
+
+	// the timeouts do not reset the consecutive failures
+	assert.Error(t, b.Execute(func() error { return errNeo4jDown }))
+	assert.Error(t, b.Execute(timeout))
+	assert.Error(t, b.Execute(func() error { return errNeo4jDown }))
+	assert.Equal(t, CircuitOpen, b.State())
+
+	// and do not close the circuit when half-open, another trial call is let through
+	*now = now.Add(time.Minute)
+	assert.Error(t, b.Execute(timeout))
+	assert.Equal(t, CircuitHalfOpen, b.State())
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.Equal(t, CircuitClosed, b.State())
+}
+
+func TestCircuitBreakerHalfOpens(t *testing.T) {
+	b, now := newTestCircuitBreaker(1, time.Minute)
+	require.Error(t, b.Execute(func() error { return errNeo4jDown }))
+
+	*now = now.Add(45 * time.Second)
+	var openErr *CircuitOpenError
+	require.True(t, errors.As(b.Execute(func() error { return nil }), &openErr))
+	assert.Equal(t, 15*time.Second, openErr.RetryAfter)
+
+	*now = now.Add(15 * time.Second)
+	assert.Equal(t, CircuitHalfOpen, b.State())
+
+	trial := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- b.Execute(func() error {
+			<-trial
+			return errNeo4jDown
+		})
+	}()
+
+	assert.Eventually(t, func() bool {
+		return errors.As(b.Execute(func() error { return nil }), &openErr)
+	}, time.Second, time.Millisecond, "only the trial call is let through while half-open")
+
+	close(trial)
+	assert.Equal(t, errNeo4jDown, <-done)
+	assert.Equal(t, CircuitOpen, b.State())
+
+	*now = now.Add(time.Minute)
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.Equal(t, CircuitClosed, b.State())
+}
+
+func TestCircuitBreakerAnnotationsCounter(t *testing.T) {
+	conceptUUIDs := []string{"601a5957-74ab-4eab-8a43-4596355c9420"}
+	metrics := map[string]Metrics{"601a5957-74ab-4eab-8a43-4596355c9420": {AnnotationsCount: 3, PrevWeekAnnotationsCount: 5}}
+
+	ac := new(MockAnnotationCounter)
+	ac.On("Count", mock.Anything, conceptUUIDs).Return(metrics, nil).Once()
+	ac.On("Count", mock.Anything, conceptUUIDs).Return(map[string]Metrics{}, errNeo4jDown).Once()
+
+	b, _ := newTestCircuitBreaker(1, time.Minute)
+	c := NewCircuitBreakerAnnotationsCounter(ac, b)
+
+	actual, err := c.Count(context.Background(), conceptUUIDs)
+	assert.NoError(t, err)
+	assert.Equal(t, metrics, actual)
+
+	_, err = c.Count(context.Background(), conceptUUIDs)
+	assert.Equal(t, errNeo4jDown, err)
+
+	_, err = c.Count(context.Background(), conceptUUIDs)
+	assert.True(t, errors.Is(err, ErrDatabaseUnavailable))
+	ac.AssertExpectations(t)
+}
@@ -0,0 +1,31 @@
+package concept
+
+import "strconv"
+
+// ConceptCSVHeader is the header row of the flattened CSV representation of Concept. New columns must only be
+// appended so that spreadsheets built on top of the export keep working.
+var ConceptCSVHeader = []string{
+	"uuid",
+	"annotationsCount",
+	"prevWeekAnnotationsCount",
+	"firstAnnotatedAt",
+	"lastAnnotatedAt",
+}
+
+// CSVRecord flattens the concept into a CSV record matching ConceptCSVHeader. Missing values are left empty.
+func (c Concept) CSVRecord() []string {
+	return []string{
+		c.UUID,
+		strconv.FormatInt(c.Metrics.AnnotationsCount, 10),
+		strconv.FormatInt(c.Metrics.PrevWeekAnnotationsCount, 10),
+		stringOrEmpty(c.Metrics.FirstAnnotatedAt),
+		stringOrEmpty(c.Metrics.LastAnnotatedAt),
+	}
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,42 @@
+package concept
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConceptCSVRecord(t *testing.T) {
+	firstAnnotatedAt := "2019-03-11T09:21:45Z"
+	lastAnnotatedAt := "2021-10-04T16:02:11Z"
+
+	tests := map[string]struct {
+		concept        Concept
+		expectedRecord []string
+	}{
+		"annotated concept": {
+			concept: Concept{
+				UUID: "601a5957-74ab-4eab-8a43-4596355c9420",
+				Metrics: Metrics{
+					AnnotationsCount:         123,
+					PrevWeekAnnotationsCount: 4,
+					FirstAnnotatedAt:         &firstAnnotatedAt,
+					LastAnnotatedAt:          &lastAnnotatedAt,
+				},
+			},
+			expectedRecord: []string{"601a5957-74ab-4eab-8a43-4596355c9420", "123", "4", "2019-03-11T09:21:45Z", "2021-10-04T16:02:11Z"},
+		},
+		"unannotated concept": {
+			concept:        Concept{UUID: "082a9fcc-5a88-48c5-bd60-64ba154204df"},
+			expectedRecord: []string{"082a9fcc-5a88-48c5-bd60-64ba154204df", "0", "0", "", ""},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			record := test.concept.CSVRecord()
+			assert.Equal(t, test.expectedRecord, record)
+			assert.Len(t, record, len(ConceptCSVHeader))
+		})
+	}
+}
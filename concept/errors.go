@@ -0,0 +1,52 @@
+package concept
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
+)
+
+// The kinds of errors the metrics can fail to be computed with. Errors are classified with WithKind and matched
+// with errors.Is.
+var (
+	// ErrValidation is the kind of the errors caused by invalid request parameters.
+	ErrValidation = errors.New("invalid request")
+	// ErrDatabaseUnavailable is the kind of the errors caused by Neo4j being unreachable or failing transiently.
+	ErrDatabaseUnavailable = errors.New("database unavailable")
+	// ErrTimeout is the kind of the errors caused by the metrics not being computed within the Neo4j transaction
+	// timeout or the deadline of the request.
+	ErrTimeout = errors.New("metrics computation timed out")
+	// ErrOverload is the kind of the errors caused by the service being too busy to accept more work.
+	ErrOverload = errors.New("service overloaded")
+)
+
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// WithKind classifies err as being of the given kind, keeping its message.
+func WithKind(kind error, err error) error {
+	return &kindError{kind: kind, err: err}
+}
+
+// queryError wraps an error returned by the Neo4j driver, classifying timeouts and unavailability.
+func queryError(err error) error {
+	wrapped := fmt.Errorf("failed executing queries: %w", err)
+	switch {
+	case errors.Is(err, neo.ErrTimeout):
+		return WithKind(ErrTimeout, wrapped)
+	case errors.Is(err, neo.ErrUnavailable):
+		return WithKind(ErrDatabaseUnavailable, wrapped)
+	}
+	return wrapped
+}
@@ -0,0 +1,51 @@
+package concept
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
+)
+
+func TestWithKind(t *testing.T) {
+	cause := errors.New("max concept UUIDs batch size is 2")
+	err := fmt.Errorf("wrapped: %w", WithKind(ErrValidation, cause))
+
+	assert.True(t, errors.Is(err, ErrValidation))
+	assert.True(t, errors.Is(err, cause))
+	assert.False(t, errors.Is(err, ErrTimeout))
+	assert.Equal(t, "wrapped: max concept UUIDs batch size is 2", err.Error())
+}
+
+func TestQueryError(t *testing.T) {
+	tests := map[string]struct {
+		err          error
+		expectedKind error
+	}{
+		"timeout": {
+			err:          fmt.Errorf("%w: Neo4jError: Neo.ClientError.Transaction.TransactionTimedOut", neo.ErrTimeout),
+			expectedKind: ErrTimeout,
+		},
+		"unavailable": {
+			err:          fmt.Errorf("%w: ConnectivityError: connection refused", neo.ErrUnavailable),
+			expectedKind: ErrDatabaseUnavailable,
+		},
+		"other": {
+			err: errors.New("Neo4jError: Neo.ClientError.Statement.SyntaxError"),
+		},
+	}
+
+	kinds := []error{ErrValidation, ErrDatabaseUnavailable, ErrTimeout, ErrOverload}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := queryError(test.err)
+			assert.True(t, errors.Is(err, test.err))
+			for _, kind := range kinds {
+				assert.Equal(t, kind == test.expectedKind, errors.Is(err, kind), kind.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package concept
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+
+	"github.com/Financial-Times/neo4j-metric-aggregator/monitoring"
+)
+
+var tracer = otel.Tracer("github.com/Financial-Times/neo4j-metric-aggregator/concept")
+
+// The query label values of neo4jQueryDuration.
+const (
+	queryCountAnnotations = "count_annotations"
+	querySummary          = "summary"
+	queryOrphans          = "orphans"
+)
+
+var (
+	neo4jQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: monitoring.Namespace,
+		Name:      "neo4j_query_duration_seconds",
+		Help:      "Duration of the Neo4j read transactions, by query.",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"query"})
+
+	batchSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: monitoring.Namespace,
+		Name:      "concepts_batch_size",
+		Help:      "Number of concepts whose metrics are requested at once.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 11),
+	})
+
+	conceptsRequested = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: monitoring.Namespace,
+		Name:      "concepts_requested_total",
+		Help:      "Number of concepts whose metrics are requested, by whether they are found.",
+	}, []string{"result"})
+
+	errorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: monitoring.Namespace,
+		Name:      "errors_total",
+		Help:      "Number of errors computing the metrics, by type.",
+	}, []string{"type"})
+)
+
+// Collectors returns the collectors of the metrics instrumenting the computation of the concept metrics.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{neo4jQueryDuration, batchSize, conceptsRequested, errorsTotal}
+}
+
+type healthCheckKey struct{}
+
+// WithHealthCheck returns a context marking the queries executed with it as health check traffic, which is neither
+// recorded in the query duration metric nor logged as slow.
+func WithHealthCheck(ctx context.Context) context.Context {
+	return context.WithValue(ctx, healthCheckKey{}, true)
+}
+
+func isHealthCheck(ctx context.Context) bool {
+	healthCheck, _ := ctx.Value(healthCheckKey{}).(bool)
+	return healthCheck
+}
+
+// observeQuery records the duration of a Neo4j read transaction started at the given time, unless it is health
+// check traffic, and adds it to the query stats of the context, if any.
+func observeQuery(ctx context.Context, query string, start time.Time) {
+	elapsed := time.Since(start)
+	if !isHealthCheck(ctx) {
+		neo4jQueryDuration.WithLabelValues(query).Observe(elapsed.Seconds())
+	}
+	addDBDuration(ctx, elapsed)
+}
+
+// errorTypes are the values of the type label of errorsTotal for each kind of error.
+var errorTypes = []struct {
+	kind error
+	name string
+}{
+	{ErrValidation, "validation"},
+	{ErrDatabaseUnavailable, "database_unavailable"},
+	{ErrTimeout, "timeout"},
+	{ErrOverload, "overload"},
+}
+
+// countError counts err in errorsTotal, with the type of its kind or internal when it has none.
+func countError(err error) {
+	for _, t := range errorTypes {
+		if errors.Is(err, t.kind) {
+			errorsTotal.WithLabelValues(t.name).Inc()
+			return
+		}
+	}
+	errorsTotal.WithLabelValues("internal").Inc()
+}
@@ -0,0 +1,114 @@
+package concept
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	logger "github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
+)
+
+func TestGetConceptMetricsInstrumentation(t *testing.T) {
+	conceptUuids := []string{
+		"601a5957-74ab-4eab-8a43-4596355c9420",
+		"082a9fcc-5a88-48c5-bd60-64ba154204df",
+		"f7885509-c029-496b-87dd-aecf1ca138d7",
+	}
+
+	countResult := map[string]Metrics{
+		"601a5957-74ab-4eab-8a43-4596355c9420": {AnnotationsCount: 3, PrevWeekAnnotationsCount: 113},
+	}
+
+	ma := new(conceptMetricsAggregator)
+	ac := new(MockAnnotationCounter)
+	ac.On("Count", mock.Anything, conceptUuids).Return(countResult, nil)
+	ma.annotationsCounter = ac
+	ma.log = logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	found := testutil.ToFloat64(conceptsRequested.WithLabelValues("found"))
+	notFound := testutil.ToFloat64(conceptsRequested.WithLabelValues("not_found"))
+
+	_, err := ma.GetConceptMetrics(context.Background(), conceptUuids)
+	assert.NoError(t, err)
+	assert.Equal(t, found+1, testutil.ToFloat64(conceptsRequested.WithLabelValues("found")))
+	assert.Equal(t, notFound+2, testutil.ToFloat64(conceptsRequested.WithLabelValues("not_found")))
+	ac.AssertExpectations(t)
+}
+
+func TestCountError(t *testing.T) {
+	tests := map[string]struct {
+		err          error
+		expectedType string
+	}{
+		"validation": {
+			err:          WithKind(ErrValidation, errors.New("bad uuid")),
+			expectedType: "validation",
+		},
+		"database unavailable": {
+			err:          queryError(neo.ErrUnavailable),
+			expectedType: "database_unavailable",
+		},
+		"timeout": {
+			err:          queryError(neo.ErrTimeout),
+			expectedType: "timeout",
+		},
+		"overload": {
+			err:          WithKind(ErrOverload, errors.New("too many requests")),
+			expectedType: "overload",
+		},
+		"internal": {
+			err:          errors.New("computer says no"),
+			expectedType: "internal",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			before := testutil.ToFloat64(errorsTotal.WithLabelValues(test.expectedType))
+			countError(test.err)
+			assert.Equal(t, before+1, testutil.ToFloat64(errorsTotal.WithLabelValues(test.expectedType)))
+		})
+	}
+}
+
+func TestCollectorsNames(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(Collectors()...)
+	batchSize.Observe(1)
+	conceptsRequested.WithLabelValues("found").Add(0)
+	errorsTotal.WithLabelValues("internal").Add(0)
+	neo4jQueryDuration.WithLabelValues(queryCountAnnotations).Observe(0)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	var names []string
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	assert.ElementsMatch(t, []string{
+		"neo4j_metric_aggregator_neo4j_query_duration_seconds",
+		"neo4j_metric_aggregator_concepts_batch_size",
+		"neo4j_metric_aggregator_concepts_requested_total",
+		"neo4j_metric_aggregator_errors_total",
+	}, names)
+}
+
+func TestObserveQuerySkipsHealthChecks(t *testing.T) {
+	ctx, stats := WithQueryStats(WithHealthCheck(context.Background()))
+	series := testutil.CollectAndCount(neo4jQueryDuration)
+
+	observeQuery(ctx, "health_check_test", time.Now())
+	assert.Equal(t, series, testutil.CollectAndCount(neo4jQueryDuration))
+	assert.Equal(t, 1, stats.Transactions())
+
+	observeQuery(context.Background(), "health_check_test", time.Now())
+	assert.Equal(t, series+1, testutil.CollectAndCount(neo4jQueryDuration))
+}
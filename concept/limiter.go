@@ -0,0 +1,82 @@
+package concept
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	log "github.com/Financial-Times/go-logger/v2"
+	tidUtils "github.com/Financial-Times/transactionid-utils-go"
+	metrics "github.com/rcrowley/go-metrics"
+	"golang.org/x/sync/semaphore"
+)
+
+// NewLimitedMetricsAggregator wraps aggregator limiting the number of concepts whose metrics are computed
+// concurrently to maxInFlightConcepts. A batch waits for up to queueTimeout for enough capacity to be released
+// before being rejected with an ErrOverload error. Batches larger than maxInFlightConcepts wait for the whole
+// capacity to be free. The number of queued batches and the rejected batches are reported in the
+// concepts.metrics.queue.depth gauge and the concepts.metrics.rejected counter of the given registry.
+func NewLimitedMetricsAggregator(aggregator MetricsAggregator, maxInFlightConcepts int64, queueTimeout time.Duration, registry metrics.Registry, log *log.UPPLogger) MetricsAggregator {
+	a := &limitedMetricsAggregator{
+		MetricsAggregator: aggregator,
+		sem:               semaphore.NewWeighted(maxInFlightConcepts),
+		capacity:          maxInFlightConcepts,
+		queueTimeout:      queueTimeout,
+		rejected:          metrics.GetOrRegisterCounter("concepts.metrics.rejected", registry),
+		log:               log,
+	}
+	registry.GetOrRegister("concepts.metrics.queue.depth", metrics.NewFunctionalGauge(a.queued.Load))
+	return a
+}
+
+type limitedMetricsAggregator struct {
+	MetricsAggregator
+	sem          *semaphore.Weighted
+	capacity     int64
+	queueTimeout time.Duration
+	queued       atomic.Int64
+	rejected     metrics.Counter
+	log          *log.UPPLogger
+}
+
+func (a *limitedMetricsAggregator) GetConceptMetrics(ctx context.Context, conceptUUIDs []string) ([]Concept, error) {
+	weight := int64(len(conceptUUIDs))
+	if weight > a.capacity {
+		weight = a.capacity
+	}
+
+	if err := a.acquire(ctx, weight); err != nil {
+		return nil, err
+	}
+	defer a.sem.Release(weight)
+
+	return a.MetricsAggregator.GetConceptMetrics(ctx, conceptUUIDs)
+}
+
+func (a *limitedMetricsAggregator) acquire(ctx context.Context, weight int64) error {
+	if a.sem.TryAcquire(weight) {
+		return nil
+	}
+
+	a.queued.Add(1)
+	defer a.queued.Add(-1)
+
+	queueCtx, cancel := context.WithTimeout(ctx, a.queueTimeout)
+	defer cancel()
+
+	err := a.sem.Acquire(queueCtx, weight)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return WithKind(ErrTimeout, fmt.Errorf("request timed out waiting to compute metrics: %w", err))
+	}
+
+	a.rejected.Inc(1)
+	a.log.WithField(tidUtils.TransactionIDKey, ctx.Value(tidUtils.TransactionIDKey)).
+		WithField("batchSize", weight).
+		Warn("rejecting concept batch, too many metrics computations in flight")
+	return WithKind(ErrOverload, fmt.Errorf("too many concept metrics computations in flight, not started within %v", a.queueTimeout))
+}
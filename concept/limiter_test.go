@@ -0,0 +1,98 @@
+package concept
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logger "github.com/Financial-Times/go-logger/v2"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// blockingMetricsAggregator computes metrics once released.
+type blockingMetricsAggregator struct {
+	MetricsAggregator
+	started  chan []string
+	released chan struct{}
+}
+
+func (a *blockingMetricsAggregator) GetConceptMetrics(ctx context.Context, conceptUUIDs []string) ([]Concept, error) {
+	a.started <- conceptUUIDs
+	<-a.released
+	return []Concept{}, nil
+}
+
+func newTestLimitedAggregator(maxInFlightConcepts int64, queueTimeout time.Duration) (MetricsAggregator, *blockingMetricsAggregator, metrics.Registry) {
+	blocking := &blockingMetricsAggregator{started: make(chan []string, 10), released: make(chan struct{})}
+	registry := metrics.NewRegistry()
+	limited := NewLimitedMetricsAggregator(blocking, maxInFlightConcepts, queueTimeout, registry, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	return limited, blocking, registry
+}
+
+func TestLimitedMetricsAggregatorRejectsWhenFull(t *testing.T) {
+	limited, blocking, registry := newTestLimitedAggregator(3, 50*time.Millisecond)
+
+	done := make(chan error)
+	go func() {
+		_, err := limited.GetConceptMetrics(context.Background(), []string{"a", "b"})
+		done <- err
+	}()
+	<-blocking.started
+
+	_, err := limited.GetConceptMetrics(context.Background(), []string{"c", "d"})
+	assert.True(t, errors.Is(err, ErrOverload))
+	assert.Equal(t, int64(1), metrics.GetOrRegisterCounter("concepts.metrics.rejected", registry).Count())
+
+	close(blocking.released)
+	assert.NoError(t, <-done)
+
+	_, err = limited.GetConceptMetrics(context.Background(), []string{"c", "d"})
+	assert.NoError(t, err)
+}
+
+func TestLimitedMetricsAggregatorQueues(t *testing.T) {
+	limited, blocking, registry := newTestLimitedAggregator(3, 10*time.Second)
+	queueDepth := registry.Get("concepts.metrics.queue.depth").(metrics.Gauge)
+
+	first := make(chan error)
+	go func() {
+		_, err := limited.GetConceptMetrics(context.Background(), []string{"a", "b"})
+		first <- err
+	}()
+	<-blocking.started
+
+	second := make(chan error)
+	go func() {
+		_, err := limited.GetConceptMetrics(context.Background(), []string{"c", "d", "e", "f"})
+		second <- err
+	}()
+
+	require.Eventually(t, func() bool { return queueDepth.Value() == 1 }, time.Second, time.Millisecond)
+	assert.Len(t, blocking.started, 0, "the second batch must wait for the first one to complete")
+
+	close(blocking.released)
+	assert.NoError(t, <-first)
+	assert.NoError(t, <-second)
+	assert.Equal(t, []string{"c", "d", "e", "f"}, <-blocking.started)
+	assert.Equal(t, int64(0), queueDepth.Value())
+	assert.Equal(t, int64(0), metrics.GetOrRegisterCounter("concepts.metrics.rejected", registry).Count())
+}
+
+func TestLimitedMetricsAggregatorRequestTimeout(t *testing.T) {
+	limited, blocking, registry := newTestLimitedAggregator(1, 10*time.Second)
+	defer close(blocking.released)
+
+	go limited.GetConceptMetrics(context.Background(), []string{"a"})
+	<-blocking.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := limited.GetConceptMetrics(ctx, []string{"b"})
+	assert.True(t, errors.Is(err, ErrTimeout))
+	assert.Equal(t, int64(0), metrics.GetOrRegisterCounter("concepts.metrics.rejected", registry).Count())
+}
@@ -4,38 +4,55 @@ import (
 	"context"
 	"fmt"
 
-	cmneo4j "github.com/Financial-Times/cm-neo4j-driver"
 	log "github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
+	"github.com/Financial-Times/neo4j-metric-aggregator/tracing"
 	tidUtils "github.com/Financial-Times/transactionid-utils-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type MetricsAggregator interface {
 	GetConceptMetrics(ctx context.Context, conceptUUIDs []string) ([]Concept, error)
+	GetSummary(ctx context.Context) (Summary, error)
+	GetOrphans(ctx context.Context, kind OrphanKind, since int64, offset int, limit int) (OrphansReport, error)
 }
 
-func NewMetricsAggregator(driver *cmneo4j.Driver, log *log.UPPLogger) MetricsAggregator {
-	ac := NewAnnotationsCounter(driver)
+// NewMetricsAggregator creates an aggregator counting the annotations with the given counter, such as the one
+// created by NewAnnotationsCounter wrapped with retries and a circuit breaker.
+func NewMetricsAggregator(driver *neo.Driver, ac AnnotationsCounter, log *log.UPPLogger) MetricsAggregator {
+	sc := NewSummaryCounter(driver)
+	of := NewOrphansFinder(driver)
 
 	return &conceptMetricsAggregator{
 		annotationsCounter: ac,
+		summaryCounter:     sc,
+		orphansFinder:      of,
 		log:                log,
 	}
 }
 
 type conceptMetricsAggregator struct {
 	annotationsCounter AnnotationsCounter
+	summaryCounter     SummaryCounter
+	orphansFinder      OrphansFinder
 	log                *log.UPPLogger
 }
 
-func (a *conceptMetricsAggregator) GetConceptMetrics(ctx context.Context, conceptUUIDs []string) ([]Concept, error) {
+func (a *conceptMetricsAggregator) GetConceptMetrics(ctx context.Context, conceptUUIDs []string) (_ []Concept, err error) {
+	ctx, span := tracer.Start(ctx, "GetConceptMetrics", trace.WithAttributes(attribute.Int("concepts.batch_size", len(conceptUUIDs))))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	logRead := a.log.
 		WithField(tidUtils.TransactionIDKey, ctx.Value(tidUtils.TransactionIDKey)).
 		WithField("batchSize", len(conceptUUIDs))
 
 	logRead.Info("computing annotations count for concept batch")
-	counts, err := a.annotationsCounter.Count(conceptUUIDs)
+	batchSize.Observe(float64(len(conceptUUIDs)))
+	counts, err := a.annotationsCounter.Count(ctx, conceptUUIDs)
 
 	if err != nil {
+		countError(err)
 		logRead.WithError(err).Error("error in getting annotations count for batch")
 		return nil, fmt.Errorf("error in getting annotations count: %w", err)
 	}
@@ -49,5 +66,40 @@ func (a *conceptMetricsAggregator) GetConceptMetrics(ctx context.Context, concep
 			concepts = append(concepts, c)
 		}
 	}
+	span.SetAttributes(attribute.Int("concepts.found", len(concepts)))
+	conceptsRequested.WithLabelValues("found").Add(float64(len(concepts)))
+	conceptsRequested.WithLabelValues("not_found").Add(float64(len(conceptUUIDs) - len(concepts)))
 	return concepts, nil
 }
+
+func (a *conceptMetricsAggregator) GetSummary(ctx context.Context) (Summary, error) {
+	logRead := a.log.WithField(tidUtils.TransactionIDKey, ctx.Value(tidUtils.TransactionIDKey))
+
+	logRead.Info("computing knowledge base summary")
+	summary, err := a.summaryCounter.Summarize(ctx)
+	if err != nil {
+		countError(err)
+		logRead.WithError(err).Error("error in computing knowledge base summary")
+		return Summary{}, fmt.Errorf("error in computing knowledge base summary: %w", err)
+	}
+
+	return summary, nil
+}
+
+func (a *conceptMetricsAggregator) GetOrphans(ctx context.Context, kind OrphanKind, since int64, offset int, limit int) (OrphansReport, error) {
+	logRead := a.log.
+		WithField(tidUtils.TransactionIDKey, ctx.Value(tidUtils.TransactionIDKey)).
+		WithField("orphanKind", kind).
+		WithField("offset", offset).
+		WithField("limit", limit)
+
+	logRead.Info("finding orphan concepts")
+	concepts, err := a.orphansFinder.Find(ctx, kind, since, offset, limit)
+	if err != nil {
+		countError(err)
+		logRead.WithError(err).Error("error in finding orphan concepts")
+		return OrphansReport{}, fmt.Errorf("error in finding orphan concepts: %w", err)
+	}
+
+	return OrphansReport{Kind: kind, Offset: offset, Limit: limit, Concepts: concepts}, nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	logger "github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
 )
 
 func TestGetConceptMetrics(t *testing.T) {
@@ -26,22 +27,22 @@ func TestGetConceptMetrics(t *testing.T) {
 
 	ma := new(conceptMetricsAggregator)
 	ac := new(MockAnnotationCounter)
-	ac.On("Count", conceptUuids).Return(countResult, nil)
+	ac.On("Count", mock.Anything, conceptUuids).Return(countResult, nil)
 	ma.annotationsCounter = ac
 	ma.log = logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
 
 	expectedConcepts := []Concept{
 		{
 			"601a5957-74ab-4eab-8a43-4596355c9420",
-			Metrics{3, 5},
+			Metrics{AnnotationsCount: 3, PrevWeekAnnotationsCount: 5},
 		},
 		{
 			"082a9fcc-5a88-48c5-bd60-64ba154204df",
-			Metrics{123, 1000},
+			Metrics{AnnotationsCount: 123, PrevWeekAnnotationsCount: 1000},
 		},
 		{
 			"f7885509-c029-496b-87dd-aecf1ca138d7",
-			Metrics{4, 1024},
+			Metrics{AnnotationsCount: 4, PrevWeekAnnotationsCount: 1024},
 		},
 	}
 
@@ -65,18 +66,18 @@ func TestGetConceptMetricsWithMissingResults(t *testing.T) {
 
 	ma := new(conceptMetricsAggregator)
 	ac := new(MockAnnotationCounter)
-	ac.On("Count", conceptUuids).Return(countResult, nil)
+	ac.On("Count", mock.Anything, conceptUuids).Return(countResult, nil)
 	ma.annotationsCounter = ac
 	ma.log = logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
 
 	expectedConcepts := []Concept{
 		{
 			"601a5957-74ab-4eab-8a43-4596355c9420",
-			Metrics{3, 113},
+			Metrics{AnnotationsCount: 3, PrevWeekAnnotationsCount: 113},
 		},
 		{
 			"f7885509-c029-496b-87dd-aecf1ca138d7",
-			Metrics{4, 1024},
+			Metrics{AnnotationsCount: 4, PrevWeekAnnotationsCount: 1024},
 		},
 	}
 
@@ -96,7 +97,7 @@ func TestGetConceptMetricsWithNoResults(t *testing.T) {
 
 	ma := new(conceptMetricsAggregator)
 	ac := new(MockAnnotationCounter)
-	ac.On("Count", conceptUuids).Return(map[string]Metrics{}, nil)
+	ac.On("Count", mock.Anything, conceptUuids).Return(map[string]Metrics{}, nil)
 	ma.annotationsCounter = ac
 	ma.log = logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
 
@@ -118,7 +119,7 @@ func TestGetConceptMetricsError(t *testing.T) {
 
 	ma := new(conceptMetricsAggregator)
 	ac := new(MockAnnotationCounter)
-	ac.On("Count", conceptUuids).Return(map[string]Metrics{}, errors.New("computer says no"))
+	ac.On("Count", mock.Anything, conceptUuids).Return(map[string]Metrics{}, errors.New("computer says no"))
 	ma.annotationsCounter = ac
 	ma.log = logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
 
@@ -127,11 +128,108 @@ func TestGetConceptMetricsError(t *testing.T) {
 	ac.AssertExpectations(t)
 }
 
+func TestGetConceptMetricsTimeout(t *testing.T) {
+	conceptUuids := []string{"601a5957-74ab-4eab-8a43-4596355c9420"}
+
+	ma := new(conceptMetricsAggregator)
+	ac := new(MockAnnotationCounter)
+	ac.On("Count", mock.Anything, conceptUuids).Return(map[string]Metrics{}, queryError(neo.ErrTimeout))
+	ma.annotationsCounter = ac
+	ma.log = logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	_, err := ma.GetConceptMetrics(context.Background(), conceptUuids)
+	assert.True(t, errors.Is(err, ErrTimeout))
+	ac.AssertExpectations(t)
+}
+
+func TestGetSummary(t *testing.T) {
+	summary := Summary{
+		CanonicalConceptsCount:   10,
+		ConceptsByType:           map[string]int64{"Person": 6, "Organisation": 4},
+		ContentCount:             42,
+		UnannotatedConceptsCount: 3,
+		AnnotationsPerConcept:    Distribution{Min: 0, P50: 2, P75: 5, P90: 12, P95: 20, P99: 30, Max: 31},
+	}
+
+	ma := new(conceptMetricsAggregator)
+	sc := new(MockSummaryCounter)
+	sc.On("Summarize", mock.Anything).Return(summary, nil)
+	ma.summaryCounter = sc
+	ma.log = logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	actualSummary, err := ma.GetSummary(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, summary, actualSummary)
+	sc.AssertExpectations(t)
+}
+
+func TestGetSummaryError(t *testing.T) {
+	ma := new(conceptMetricsAggregator)
+	sc := new(MockSummaryCounter)
+	sc.On("Summarize", mock.Anything).Return(Summary{}, errors.New("computer says no"))
+	ma.summaryCounter = sc
+	ma.log = logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	_, err := ma.GetSummary(context.Background())
+	assert.Error(t, err)
+	sc.AssertExpectations(t)
+}
+
+func TestGetOrphans(t *testing.T) {
+	concepts := []OrphanConcept{
+		{UUID: "601a5957-74ab-4eab-8a43-4596355c9420", PrefLabel: "Jane Doe", Types: []string{"Person"}},
+		{UUID: "f7885509-c029-496b-87dd-aecf1ca138d7", PrefLabel: "Acme", Types: []string{"Organisation"}},
+	}
+
+	ma := new(conceptMetricsAggregator)
+	of := new(MockOrphansFinder)
+	of.On("Find", mock.Anything, OrphanKindUnannotated, int64(1600000000), 20, 10).Return(concepts, nil)
+	ma.orphansFinder = of
+	ma.log = logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	expectedReport := OrphansReport{Kind: OrphanKindUnannotated, Offset: 20, Limit: 10, Concepts: concepts}
+
+	actualReport, err := ma.GetOrphans(context.Background(), OrphanKindUnannotated, 1600000000, 20, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedReport, actualReport)
+	of.AssertExpectations(t)
+}
+
+func TestGetOrphansError(t *testing.T) {
+	ma := new(conceptMetricsAggregator)
+	of := new(MockOrphansFinder)
+	of.On("Find", mock.Anything, OrphanKindUnsourced, int64(0), 0, 10).Return([]OrphanConcept{}, errors.New("computer says no"))
+	ma.orphansFinder = of
+	ma.log = logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	_, err := ma.GetOrphans(context.Background(), OrphanKindUnsourced, 0, 0, 10)
+	assert.Error(t, err)
+	of.AssertExpectations(t)
+}
+
 type MockAnnotationCounter struct {
 	mock.Mock
 }
 
-func (m *MockAnnotationCounter) Count(conceptUUIDs []string) (map[string]Metrics, error) {
-	args := m.Called(conceptUUIDs)
+func (m *MockAnnotationCounter) Count(ctx context.Context, conceptUUIDs []string) (map[string]Metrics, error) {
+	args := m.Called(ctx, conceptUUIDs)
 	return args.Get(0).(map[string]Metrics), args.Error(1)
 }
+
+type MockSummaryCounter struct {
+	mock.Mock
+}
+
+func (m *MockSummaryCounter) Summarize(ctx context.Context) (Summary, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(Summary), args.Error(1)
+}
+
+type MockOrphansFinder struct {
+	mock.Mock
+}
+
+func (m *MockOrphansFinder) Find(ctx context.Context, kind OrphanKind, since int64, offset int, limit int) ([]OrphanConcept, error) {
+	args := m.Called(ctx, kind, since, offset, limit)
+	return args.Get(0).([]OrphanConcept), args.Error(1)
+}
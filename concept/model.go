@@ -6,12 +6,82 @@ type Concept struct {
 }
 
 type Metrics struct {
-	AnnotationsCount         int64 `json:"annotationsCount"`
-	PrevWeekAnnotationsCount int64 `json:"prevWeekAnnotationsCount"`
+	AnnotationsCount         int64   `json:"annotationsCount"`
+	PrevWeekAnnotationsCount int64   `json:"prevWeekAnnotationsCount"`
+	FirstAnnotatedAt         *string `json:"firstAnnotatedAt"`
+	LastAnnotatedAt          *string `json:"lastAnnotatedAt"`
 }
 
 type NeoMetricResult struct {
-	UUID        string `json:"uuid"`
-	RecentCount int64  `json:"recentCount"`
-	TotalCount  int64  `json:"totalCount"`
+	UUID                    string `json:"uuid"`
+	RecentCount             int64  `json:"recentCount"`
+	TotalCount              int64  `json:"totalCount"`
+	FirstPublishedDateEpoch *int64 `json:"firstPublishedDateEpoch"`
+	LastPublishedDateEpoch  *int64 `json:"lastPublishedDateEpoch"`
+}
+
+type Summary struct {
+	CanonicalConceptsCount   int64            `json:"canonicalConceptsCount"`
+	ConceptsByType           map[string]int64 `json:"conceptsByType"`
+	ContentCount             int64            `json:"contentCount"`
+	UnannotatedConceptsCount int64            `json:"unannotatedConceptsCount"`
+	AnnotationsPerConcept    Distribution     `json:"annotationsPerConcept"`
+}
+
+type Distribution struct {
+	Min int64 `json:"min"`
+	P50 int64 `json:"p50"`
+	P75 int64 `json:"p75"`
+	P90 int64 `json:"p90"`
+	P95 int64 `json:"p95"`
+	P99 int64 `json:"p99"`
+	Max int64 `json:"max"`
+}
+
+type NeoConceptTypesResult struct {
+	Types []NeoConceptTypeCount `json:"types"`
+}
+
+type NeoConceptTypeCount struct {
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+type NeoCountResult struct {
+	Count int64 `json:"count"`
+}
+
+type NeoAnnotationsDistributionResult struct {
+	Total       int64 `json:"total"`
+	Unannotated int64 `json:"unannotated"`
+	Min         int64 `json:"min"`
+	P50         int64 `json:"p50"`
+	P75         int64 `json:"p75"`
+	P90         int64 `json:"p90"`
+	P95         int64 `json:"p95"`
+	P99         int64 `json:"p99"`
+	Max         int64 `json:"max"`
+}
+
+type OrphansReport struct {
+	Kind     OrphanKind      `json:"kind"`
+	Offset   int             `json:"offset"`
+	Limit    int             `json:"limit"`
+	Concepts []OrphanConcept `json:"concepts"`
+}
+
+type OrphanConcept struct {
+	UUID      string   `json:"uuid"`
+	PrefLabel string   `json:"prefLabel"`
+	Types     []string `json:"types"`
+}
+
+type NeoOrphansResult struct {
+	Concepts []NeoOrphanConcept `json:"concepts"`
+}
+
+type NeoOrphanConcept struct {
+	UUID      string   `json:"uuid"`
+	PrefLabel string   `json:"prefLabel"`
+	Labels    []string `json:"types"`
 }
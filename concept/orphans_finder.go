@@ -0,0 +1,122 @@
+package concept
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
+)
+
+type OrphanKind string
+
+const (
+	// OrphanKindUnannotated denotes canonical concepts without annotations, optionally since a given time.
+	OrphanKindUnannotated OrphanKind = "unannotated"
+	// OrphanKindUnsourced denotes canonical concepts without any EQUIVALENT_TO source concepts.
+	OrphanKindUnsourced OrphanKind = "unsourced"
+	// OrphanKindUnattached denotes source concepts which are not EQUIVALENT_TO any canonical concept.
+	OrphanKindUnattached OrphanKind = "unattached"
+)
+
+var OrphanKinds = []OrphanKind{OrphanKindUnannotated, OrphanKindUnsourced, OrphanKindUnattached}
+
+// findUnannotatedConceptsQuery is formatted with the filter of the annotations, either empty or
+// publishedSinceFilter, for the content without a publishedDateEpoch to count as an annotation when there is no
+// since time.
+const findUnannotatedConceptsQuery = `
+	MATCH (canonicalConcept:Concept)
+	WHERE canonicalConcept.prefUUID IS NOT NULL
+	OPTIONAL MATCH (canonicalConcept)<-[:EQUIVALENT_TO]-(source:Concept)
+	OPTIONAL MATCH (source)<-[]-(content:Content)
+	%s
+	WITH canonicalConcept, count(DISTINCT(content)) AS annotationsCount
+	WHERE annotationsCount = 0
+	WITH canonicalConcept ORDER BY canonicalConcept.prefUUID SKIP $offset LIMIT $limit
+	RETURN collect({uuid: canonicalConcept.prefUUID, prefLabel: canonicalConcept.prefLabel, types: labels(canonicalConcept)}) AS concepts
+`
+
+const publishedSinceFilter = "WHERE content.publishedDateEpoch > $since"
+
+const findUnsourcedConceptsQuery = `
+	MATCH (canonicalConcept:Concept)
+	WHERE canonicalConcept.prefUUID IS NOT NULL AND NOT (canonicalConcept)<-[:EQUIVALENT_TO]-(:Concept)
+	WITH canonicalConcept ORDER BY canonicalConcept.prefUUID SKIP $offset LIMIT $limit
+	RETURN collect({uuid: canonicalConcept.prefUUID, prefLabel: canonicalConcept.prefLabel, types: labels(canonicalConcept)}) AS concepts
+`
+
+const findUnattachedConceptsQuery = `
+	MATCH (source:Concept)
+	WHERE source.uuid IS NOT NULL AND NOT (source)-[:EQUIVALENT_TO]->(:Concept)
+	WITH source ORDER BY source.uuid SKIP $offset LIMIT $limit
+	RETURN collect({uuid: source.uuid, prefLabel: source.prefLabel, types: labels(source)}) AS concepts
+`
+
+type OrphansFinder interface {
+	Find(ctx context.Context, kind OrphanKind, since int64, offset int, limit int) ([]OrphanConcept, error)
+}
+
+func NewOrphansFinder(driver *neo.Driver) OrphansFinder {
+	return &neoOrphansFinder{driver}
+}
+
+type neoOrphansFinder struct {
+	driver *neo.Driver
+}
+
+// Find returns a page of the concepts of the given orphan kind, ordered by their uuid. The since unix epoch is
+// only taken into account for OrphanKindUnannotated, where annotations published before it are disregarded.
+func (f *neoOrphansFinder) Find(ctx context.Context, kind OrphanKind, since int64, offset int, limit int) ([]OrphanConcept, error) {
+	var cypher string
+	switch kind {
+	case OrphanKindUnannotated:
+		filter := ""
+		if since > 0 {
+			filter = publishedSinceFilter
+		}
+		cypher = fmt.Sprintf(findUnannotatedConceptsQuery, filter)
+	case OrphanKindUnsourced:
+		cypher = findUnsourcedConceptsQuery
+	case OrphanKindUnattached:
+		cypher = findUnattachedConceptsQuery
+	default:
+		return nil, fmt.Errorf("unknown orphan kind %q", kind)
+	}
+
+	res := NeoOrphansResult{}
+	q := &neo.Query{
+		Cypher: cypher,
+		Params: map[string]interface{}{"since": since, "offset": offset, "limit": limit},
+		Result: &res,
+	}
+
+	start := time.Now()
+	err := f.driver.Read(ctx, q)
+	observeQuery(ctx, queryOrphans, start)
+	if errors.Is(err, neo.ErrNoResultsFound) {
+		// The defined queries collect their results and always return a single row.
+		return nil, fmt.Errorf("unexpected 'no result' returned from the DB: %w", err)
+	}
+	if err != nil {
+		return nil, queryError(err)
+	}
+
+	concepts := make([]OrphanConcept, 0, len(res.Concepts))
+	for _, c := range res.Concepts {
+		concepts = append(concepts, OrphanConcept{UUID: c.UUID, PrefLabel: c.PrefLabel, Types: conceptTypes(c.Labels)})
+	}
+	return concepts, nil
+}
+
+// conceptTypes filters out the labels common to all the concepts.
+func conceptTypes(labels []string) []string {
+	types := []string{}
+	for _, l := range labels {
+		if l == "Concept" || l == "Thing" {
+			continue
+		}
+		types = append(types, l)
+	}
+	return types
+}
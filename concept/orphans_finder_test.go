@@ -0,0 +1,116 @@
+// +build integration
+
+package concept
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
+)
+
+func (suite *AnnotationsCounterTestSuite) TestFindUnannotated() {
+	annotatedUUID := uuid.New().String()
+	suite.writeTestConceptWithAnnotations(annotatedUUID, 2, 10, 4)
+
+	unannotatedUUID := uuid.New().String()
+	suite.writeTestConceptWithAnnotations(unannotatedUUID, 1, 0, 0)
+	suite.addConceptLabel(unannotatedUUID, "Person")
+
+	of := NewOrphansFinder(suite.driver)
+	concepts, err := of.Find(context.Background(), OrphanKindUnannotated, 0, 0, 10)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []OrphanConcept{{UUID: unannotatedUUID, Types: []string{"Person"}}}, concepts)
+}
+
+func (suite *AnnotationsCounterTestSuite) TestFindUnannotatedWithoutPublishedDate() {
+	conceptUUID := uuid.New().String()
+	suite.writeTestConceptWithAnnotations(conceptUUID, 1, 0, 0)
+	err := suite.driver.Write(&neo.Query{
+		Cypher: "MATCH (:Concept{prefUUID: $prefUUID})<-[:EQUIVALENT_TO]-(source:Concept) CREATE (source)<-[:REL]-(:Content)",
+		Params: map[string]interface{}{"prefUUID": conceptUUID},
+	})
+	require.NoError(suite.T(), err)
+
+	metrics, err := NewAnnotationsCounter(suite.driver, 0, suite.log).Count(context.Background(), []string{conceptUUID})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, metrics[conceptUUID].AnnotationsCount)
+
+	of := NewOrphansFinder(suite.driver)
+	concepts, err := of.Find(context.Background(), OrphanKindUnannotated, 0, 0, 10)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), concepts)
+}
+
+func (suite *AnnotationsCounterTestSuite) TestFindUnannotatedSince() {
+	recentlyAnnotatedUUID := uuid.New().String()
+	suite.writeTestConceptWithAnnotations(recentlyAnnotatedUUID, 2, 10, 4)
+
+	previouslyAnnotatedUUID := uuid.New().String()
+	suite.writeTestConceptWithAnnotations(previouslyAnnotatedUUID, 1, 5, 0)
+
+	of := NewOrphansFinder(suite.driver)
+	since := time.Now().Add(-7 * 24 * time.Hour).Unix()
+	concepts, err := of.Find(context.Background(), OrphanKindUnannotated, since, 0, 10)
+	assert.NoError(suite.T(), err)
+	require.Len(suite.T(), concepts, 1)
+	assert.Equal(suite.T(), previouslyAnnotatedUUID, concepts[0].UUID)
+}
+
+func (suite *AnnotationsCounterTestSuite) TestFindUnsourced() {
+	sourcedUUID := uuid.New().String()
+	suite.writeTestConceptWithAnnotations(sourcedUUID, 2, 1, 0)
+
+	unsourcedUUID := uuid.New().String()
+	suite.writeTestConceptWithAnnotations(unsourcedUUID, 0, 0, 0)
+
+	of := NewOrphansFinder(suite.driver)
+	concepts, err := of.Find(context.Background(), OrphanKindUnsourced, 0, 0, 10)
+	assert.NoError(suite.T(), err)
+	require.Len(suite.T(), concepts, 1)
+	assert.Equal(suite.T(), unsourcedUUID, concepts[0].UUID)
+}
+
+func (suite *AnnotationsCounterTestSuite) TestFindUnattached() {
+	suite.writeTestConceptWithAnnotations(uuid.New().String(), 2, 1, 0)
+
+	unattachedUUID := uuid.New().String()
+	err := suite.driver.Write(&neo.Query{
+		Cypher: "CREATE (:Concept:Thing:Topic{uuid: $uuid, prefLabel: $prefLabel})",
+		Params: map[string]interface{}{"uuid": unattachedUUID, "prefLabel": "Lonely topic"},
+	})
+	require.NoError(suite.T(), err)
+
+	of := NewOrphansFinder(suite.driver)
+	concepts, err := of.Find(context.Background(), OrphanKindUnattached, 0, 0, 10)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []OrphanConcept{{UUID: unattachedUUID, PrefLabel: "Lonely topic", Types: []string{"Topic"}}}, concepts)
+}
+
+func (suite *AnnotationsCounterTestSuite) TestFindPagination() {
+	var uuids []string
+	for i := 0; i < 5; i++ {
+		conceptUUID := uuid.New().String()
+		suite.writeTestConceptWithAnnotations(conceptUUID, 0, 0, 0)
+		uuids = append(uuids, conceptUUID)
+	}
+
+	of := NewOrphansFinder(suite.driver)
+	firstPage, err := of.Find(context.Background(), OrphanKindUnsourced, 0, 0, 3)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), firstPage, 3)
+
+	secondPage, err := of.Find(context.Background(), OrphanKindUnsourced, 0, 3, 3)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), secondPage, 2)
+
+	var found []string
+	for _, c := range append(firstPage, secondPage...) {
+		found = append(found, c.UUID)
+	}
+	assert.ElementsMatch(suite.T(), uuids, found)
+}
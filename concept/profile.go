@@ -0,0 +1,55 @@
+package concept
+
+import (
+	"context"
+	"sync"
+)
+
+// QueryProfile sums up the execution of the query counting the annotations of a concept.
+type QueryProfile struct {
+	UUID       string  `json:"uuid"`
+	DurationMs float64 `json:"durationMs"`
+	DBHits     int64   `json:"dbHits"`
+	Rows       int64   `json:"rows"`
+}
+
+type profilesKey struct{}
+
+type profiles struct {
+	mu   sync.Mutex
+	list []QueryProfile
+}
+
+// WithProfiling returns a context profiling the queries counting the annotations of the concepts, whose
+// profiles are then returned by Profiles.
+func WithProfiling(ctx context.Context) context.Context {
+	return context.WithValue(ctx, profilesKey{}, &profiles{})
+}
+
+// Profiles returns the profiles of the queries executed with a context returned by WithProfiling.
+func Profiles(ctx context.Context) []QueryProfile {
+	p, ok := ctx.Value(profilesKey{}).(*profiles)
+	if !ok {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]QueryProfile{}, p.list...)
+}
+
+func isProfiling(ctx context.Context) bool {
+	_, ok := ctx.Value(profilesKey{}).(*profiles)
+	return ok
+}
+
+func addProfiles(ctx context.Context, list []QueryProfile) {
+	p, ok := ctx.Value(profilesKey{}).(*profiles)
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.list = append(p.list, list...)
+}
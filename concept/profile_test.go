@@ -0,0 +1,40 @@
+package concept
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
+)
+
+func TestProfiles(t *testing.T) {
+	assert.Nil(t, Profiles(context.Background()))
+	assert.False(t, isProfiling(context.Background()))
+
+	ctx := WithProfiling(context.Background())
+	assert.True(t, isProfiling(ctx))
+	assert.Equal(t, []QueryProfile{}, Profiles(ctx))
+
+	addProfiles(ctx, []QueryProfile{{UUID: "601a5957-74ab-4eab-8a43-4596355c9420", DBHits: 12, Rows: 1}})
+	addProfiles(ctx, []QueryProfile{{UUID: "f7885509-c029-496b-87dd-aecf1ca138d7", DBHits: 4, Rows: 1}})
+	assert.Equal(t, []QueryProfile{
+		{UUID: "601a5957-74ab-4eab-8a43-4596355c9420", DBHits: 12, Rows: 1},
+		{UUID: "f7885509-c029-496b-87dd-aecf1ca138d7", DBHits: 4, Rows: 1},
+	}, Profiles(ctx))
+}
+
+func TestQueryProfiles(t *testing.T) {
+	conceptUUIDs := []string{"601a5957-74ab-4eab-8a43-4596355c9420", "f7885509-c029-496b-87dd-aecf1ca138d7"}
+	queries := buildQueries(conceptUUIDs, true)
+	assert.True(t, queries[0].Profile)
+
+	queries[0].Duration = 1500 * time.Microsecond
+	queries[0].ProfileSummary = &neo.ProfileSummary{DBHits: 42, Rows: 1}
+
+	assert.Equal(t, []QueryProfile{
+		{UUID: "601a5957-74ab-4eab-8a43-4596355c9420", DurationMs: 1.5, DBHits: 42, Rows: 1},
+	}, queryProfiles(conceptUUIDs, queries))
+}
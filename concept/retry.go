@@ -0,0 +1,115 @@
+package concept
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	log "github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
+	tidUtils "github.com/Financial-Times/transactionid-utils-go"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// RetryPolicy defines how many times and how long apart the transient Neo4j errors are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the upper bound of the delay before the first retry, doubled for every further retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the upper bound of the delay between retries.
+	MaxBackoff time.Duration
+}
+
+// backoff returns a random delay before the given retry, starting from 1, between zero and the exponentially
+// growing upper bound, so that the retries of concurrent requests are spread out.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	bound := p.InitialBackoff << (retry - 1)
+	if bound > p.MaxBackoff || bound <= 0 {
+		bound = p.MaxBackoff
+	}
+	if bound <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(bound)) + 1)
+}
+
+// NewRetryingAnnotationsCounter wraps counter retrying the counts failed with transient Neo4j errors, as long as
+// the retry can be made before the deadline of the context. The retries are counted in the neo4j.retries
+// counter of the given registry.
+func NewRetryingAnnotationsCounter(counter AnnotationsCounter, policy RetryPolicy, registry metrics.Registry, log *log.UPPLogger) AnnotationsCounter {
+	return &retryingAnnotationsCounter{
+		counter:   counter,
+		policy:    policy,
+		retries:   metrics.GetOrRegisterCounter("neo4j.retries", registry),
+		exhausted: metrics.GetOrRegisterCounter("neo4j.retries.exhausted", registry),
+		log:       log,
+		sleep:     sleepContext,
+	}
+}
+
+type retryingAnnotationsCounter struct {
+	counter   AnnotationsCounter
+	policy    RetryPolicy
+	retries   metrics.Counter
+	exhausted metrics.Counter
+	log       *log.UPPLogger
+	sleep     func(ctx context.Context, d time.Duration) error
+}
+
+func (c *retryingAnnotationsCounter) Count(ctx context.Context, conceptUUIDs []string) (map[string]Metrics, error) {
+	logRetry := c.log.
+		WithField(tidUtils.TransactionIDKey, ctx.Value(tidUtils.TransactionIDKey)).
+		WithField("batchSize", len(conceptUUIDs))
+
+	for attempt := 1; ; attempt++ {
+		counts, err := c.counter.Count(ctx, conceptUUIDs)
+		if err == nil || !isTransient(err) {
+			if attempt > 1 {
+				logRetry.WithField("attempts", attempt).Info("counting annotations completed after retries")
+			}
+			return counts, err
+		}
+
+		if attempt >= c.policy.MaxAttempts {
+			c.exhausted.Inc(1)
+			logRetry.WithError(err).WithField("attempts", attempt).Error("giving up retrying transient Neo4j error")
+			return nil, err
+		}
+
+		delay := c.policy.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			c.exhausted.Inc(1)
+			logRetry.WithError(err).WithField("attempts", attempt).Error("no request budget left to retry transient Neo4j error")
+			return nil, err
+		}
+
+		c.retries.Inc(1)
+		logRetry.WithError(err).
+			WithField("attempt", attempt).
+			WithField("backoff", delay.String()).
+			Warn("retrying transient Neo4j error")
+		if sleepErr := c.sleep(ctx, delay); sleepErr != nil {
+			return nil, err
+		}
+	}
+}
+
+// isTransient reports whether err is worth retrying. The errors the driver already gave up retrying are not.
+func isTransient(err error) bool {
+	var openErr *CircuitOpenError
+	return errors.Is(err, ErrDatabaseUnavailable) && !errors.As(err, &openErr) && !errors.Is(err, neo.ErrRetriesExhausted)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,124 @@
+package concept
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	logger "github.com/Financial-Times/go-logger/v2"
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
+)
+
+var testRetryPolicy = RetryPolicy{MaxAttempts: 3, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+func newTestRetryingCounter(ac AnnotationsCounter, registry metrics.Registry) (*retryingAnnotationsCounter, *[]time.Duration) {
+	c := NewRetryingAnnotationsCounter(ac, testRetryPolicy, registry, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")).(*retryingAnnotationsCounter)
+	var delays []time.Duration
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	return c, &delays
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	for retry, bound := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		3:  400 * time.Millisecond,
+		5:  time.Second,
+		70: time.Second,
+	} {
+		for i := 0; i < 100; i++ {
+			delay := testRetryPolicy.backoff(retry)
+			assert.True(t, delay > 0 && delay <= bound, "retry %v got delay %v", retry, delay)
+		}
+	}
+}
+
+func TestRetryingAnnotationsCounterRecovers(t *testing.T) {
+	conceptUUIDs := []string{"601a5957-74ab-4eab-8a43-4596355c9420"}
+	counts := map[string]Metrics{"601a5957-74ab-4eab-8a43-4596355c9420": {AnnotationsCount: 3, PrevWeekAnnotationsCount: 5}}
+
+	ac := new(MockAnnotationCounter)
+	ac.On("Count", mock.Anything, conceptUUIDs).Return(map[string]Metrics{}, errNeo4jDown).Twice()
+	ac.On("Count", mock.Anything, conceptUUIDs).Return(counts, nil).Once()
+
+	registry := metrics.NewRegistry()
+	c, delays := newTestRetryingCounter(ac, registry)
+
+	actual, err := c.Count(context.Background(), conceptUUIDs)
+	assert.NoError(t, err)
+	assert.Equal(t, counts, actual)
+	assert.Len(t, *delays, 2)
+	assert.Equal(t, int64(2), metrics.GetOrRegisterCounter("neo4j.retries", registry).Count())
+	assert.Equal(t, int64(0), metrics.GetOrRegisterCounter("neo4j.retries.exhausted", registry).Count())
+	ac.AssertExpectations(t)
+}
+
+func TestRetryingAnnotationsCounterGivesUp(t *testing.T) {
+	conceptUUIDs := []string{"601a5957-74ab-4eab-8a43-4596355c9420"}
+
+	ac := new(MockAnnotationCounter)
+	ac.On("Count", mock.Anything, conceptUUIDs).Return(map[string]Metrics{}, errNeo4jDown).Times(3)
+
+	registry := metrics.NewRegistry()
+	c, delays := newTestRetryingCounter(ac, registry)
+
+	_, err := c.Count(context.Background(), conceptUUIDs)
+	assert.Equal(t, errNeo4jDown, err)
+	assert.Len(t, *delays, 2)
+	assert.Equal(t, int64(2), metrics.GetOrRegisterCounter("neo4j.retries", registry).Count())
+	assert.Equal(t, int64(1), metrics.GetOrRegisterCounter("neo4j.retries.exhausted", registry).Count())
+	ac.AssertExpectations(t)
+}
+
+func TestRetryingAnnotationsCounterDoesNotRetryPermanentErrors(t *testing.T) {
+	conceptUUIDs := []string{"601a5957-74ab-4eab-8a43-4596355c9420"}
+	permanentErrors := map[string]error{
+		"timeout":                  WithKind(ErrTimeout, errors.New("failed executing queries: neo4j transaction timed out")),
+		"circuit open":             &CircuitOpenError{RetryAfter: time.Second},
+		"driver retries exhausted": queryError(fmt.Errorf("%w: %w: Timeout after 30s", neo.ErrUnavailable, neo.ErrRetriesExhausted)),
+		"other":                    errors.New("failed executing queries: Neo4jError: Neo.ClientError.Statement.SyntaxError"),
+	}
+
+	for name, permanentErr := range permanentErrors {
+		t.Run(name, func(t *testing.T) {
+			ac := new(MockAnnotationCounter)
+			ac.On("Count", mock.Anything, conceptUUIDs).Return(map[string]Metrics{}, permanentErr).Once()
+
+			c, delays := newTestRetryingCounter(ac, metrics.NewRegistry())
+
+			_, err := c.Count(context.Background(), conceptUUIDs)
+			assert.Equal(t, permanentErr, err)
+			assert.Empty(t, *delays)
+			ac.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRetryingAnnotationsCounterStopsAtDeadline(t *testing.T) {
+	conceptUUIDs := []string{"601a5957-74ab-4eab-8a43-4596355c9420"}
+
+	ac := new(MockAnnotationCounter)
+	ac.On("Count", mock.Anything, conceptUUIDs).Return(map[string]Metrics{}, errNeo4jDown).Once()
+
+	registry := metrics.NewRegistry()
+	c, delays := newTestRetryingCounter(ac, registry)
+
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	_, err := c.Count(ctx, conceptUUIDs)
+	assert.Equal(t, errNeo4jDown, err)
+	assert.Empty(t, *delays)
+	assert.Equal(t, int64(1), metrics.GetOrRegisterCounter("neo4j.retries.exhausted", registry).Count())
+	ac.AssertExpectations(t)
+}
@@ -0,0 +1,11 @@
+package concept
+
+import "github.com/Financial-Times/neo4j-metric-aggregator/neo"
+
+// RequiredIndexes are the indexes the queries computing the metrics rely on, to look up the concepts by prefUUID
+// and uuid and the content by publish date without scanning all the nodes.
+var RequiredIndexes = []neo.Index{
+	{Label: "Concept", Property: "prefUUID"},
+	{Label: "Concept", Property: "uuid"},
+	{Label: "Content", Property: "publishedDateEpoch"},
+}
@@ -0,0 +1,49 @@
+package concept
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type queryStatsKey struct{}
+
+// QueryStats accumulates the time spent in the Neo4j transactions executed with a context returned by
+// WithQueryStats, including the retried ones.
+type QueryStats struct {
+	mu           sync.Mutex
+	dbDuration   time.Duration
+	transactions int
+}
+
+// WithQueryStats returns a context accumulating the time spent in Neo4j in the returned stats.
+func WithQueryStats(ctx context.Context) (context.Context, *QueryStats) {
+	stats := &QueryStats{}
+	return context.WithValue(ctx, queryStatsKey{}, stats), stats
+}
+
+// DBDuration returns the time spent in the Neo4j transactions.
+func (s *QueryStats) DBDuration() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dbDuration
+}
+
+// Transactions returns the number of Neo4j transactions executed.
+func (s *QueryStats) Transactions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.transactions
+}
+
+func addDBDuration(ctx context.Context, d time.Duration) {
+	stats, ok := ctx.Value(queryStatsKey{}).(*QueryStats)
+	if !ok {
+		return
+	}
+
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	stats.dbDuration += d
+	stats.transactions++
+}
@@ -0,0 +1,34 @@
+package concept
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryStats(t *testing.T) {
+	ctx, stats := WithQueryStats(context.Background())
+
+	addDBDuration(ctx, 20*time.Millisecond)
+	addDBDuration(ctx, 30*time.Millisecond)
+
+	assert.Equal(t, 50*time.Millisecond, stats.DBDuration())
+	assert.Equal(t, 2, stats.Transactions())
+}
+
+func TestQueryStatsObserveQuery(t *testing.T) {
+	ctx, stats := WithQueryStats(context.Background())
+
+	observeQuery(ctx, queryCountAnnotations, time.Now().Add(-time.Second))
+
+	assert.GreaterOrEqual(t, stats.DBDuration(), time.Second)
+	assert.Equal(t, 1, stats.Transactions())
+}
+
+func TestQueryStatsWithoutStats(t *testing.T) {
+	assert.NotPanics(t, func() {
+		addDBDuration(context.Background(), time.Second)
+	})
+}
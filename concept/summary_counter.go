@@ -0,0 +1,99 @@
+package concept
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
+)
+
+const countCanonicalConceptsByTypeQuery = `
+	MATCH (canonicalConcept:Concept)
+	WHERE canonicalConcept.prefUUID IS NOT NULL
+	UNWIND labels(canonicalConcept) AS label
+	WITH label, count(canonicalConcept) AS count
+	WHERE NOT label IN ['Concept', 'Thing']
+	RETURN collect({label: label, count: count}) AS types
+`
+
+const countContentQuery = `
+	MATCH (content:Content)
+	RETURN count(content) AS count
+`
+
+const annotationsDistributionQuery = `
+	MATCH (canonicalConcept:Concept)
+	WHERE canonicalConcept.prefUUID IS NOT NULL
+	OPTIONAL MATCH (canonicalConcept)<-[:EQUIVALENT_TO]-(source:Concept)
+	OPTIONAL MATCH (source)<-[]-(content:Content)
+	WITH canonicalConcept, count(DISTINCT(content)) AS annotationsCount
+	RETURN count(canonicalConcept) AS total,
+		sum(CASE annotationsCount WHEN 0 THEN 1 ELSE 0 END) AS unannotated,
+		coalesce(min(annotationsCount), 0) AS min,
+		coalesce(percentileDisc(annotationsCount, 0.5), 0) AS p50,
+		coalesce(percentileDisc(annotationsCount, 0.75), 0) AS p75,
+		coalesce(percentileDisc(annotationsCount, 0.9), 0) AS p90,
+		coalesce(percentileDisc(annotationsCount, 0.95), 0) AS p95,
+		coalesce(percentileDisc(annotationsCount, 0.99), 0) AS p99,
+		coalesce(max(annotationsCount), 0) AS max
+`
+
+type SummaryCounter interface {
+	Summarize(ctx context.Context) (Summary, error)
+}
+
+func NewSummaryCounter(driver *neo.Driver) SummaryCounter {
+	return &neoSummaryCounter{driver}
+}
+
+type neoSummaryCounter struct {
+	driver *neo.Driver
+}
+
+// Summarize returns knowledge base wide statistics. The queries scan every canonical concept and content in the
+// DB, so they are considerably more expensive than the ones issued by AnnotationsCounter.
+func (c *neoSummaryCounter) Summarize(ctx context.Context) (Summary, error) {
+	typesRes := NeoConceptTypesResult{}
+	contentRes := NeoCountResult{}
+	distributionRes := NeoAnnotationsDistributionResult{}
+
+	queries := []*neo.Query{
+		{Cypher: countCanonicalConceptsByTypeQuery, Result: &typesRes},
+		{Cypher: countContentQuery, Result: &contentRes},
+		{Cypher: annotationsDistributionQuery, Result: &distributionRes},
+	}
+
+	start := time.Now()
+	err := c.driver.Read(ctx, queries...)
+	observeQuery(ctx, querySummary, start)
+	if errors.Is(err, neo.ErrNoResultsFound) {
+		// All the defined queries are aggregations and always return a single row.
+		return Summary{}, fmt.Errorf("unexpected 'no result' returned from the DB: %w", err)
+	}
+	if err != nil {
+		return Summary{}, queryError(err)
+	}
+
+	conceptsByType := make(map[string]int64)
+	for _, t := range typesRes.Types {
+		conceptsByType[t.Label] = t.Count
+	}
+
+	return Summary{
+		CanonicalConceptsCount:   distributionRes.Total,
+		ConceptsByType:           conceptsByType,
+		ContentCount:             contentRes.Count,
+		UnannotatedConceptsCount: distributionRes.Unannotated,
+		AnnotationsPerConcept: Distribution{
+			Min: distributionRes.Min,
+			P50: distributionRes.P50,
+			P75: distributionRes.P75,
+			P90: distributionRes.P90,
+			P95: distributionRes.P95,
+			P99: distributionRes.P99,
+			Max: distributionRes.Max,
+		},
+	}, nil
+}
@@ -0,0 +1,59 @@
+// +build integration
+
+package concept
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
+)
+
+func (suite *AnnotationsCounterTestSuite) TestSummarize() {
+	conceptUUID1 := uuid.New().String()
+	suite.writeTestConceptWithAnnotations(conceptUUID1, 3, 25, 9)
+	suite.addConceptLabel(conceptUUID1, "Person")
+
+	conceptUUID2 := uuid.New().String()
+	suite.writeTestConceptWithAnnotations(conceptUUID2, 1, 10, 4)
+	suite.addConceptLabel(conceptUUID2, "Person")
+
+	conceptUUID3 := uuid.New().String()
+	suite.writeTestConceptWithAnnotations(conceptUUID3, 2, 0, 0)
+	suite.addConceptLabel(conceptUUID3, "Topic")
+
+	sc := NewSummaryCounter(suite.driver)
+	summary, err := sc.Summarize(context.Background())
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), int64(3), summary.CanonicalConceptsCount)
+	assert.Equal(suite.T(), map[string]int64{"Person": 2, "Topic": 1}, summary.ConceptsByType)
+	assert.Equal(suite.T(), int64(35), summary.ContentCount)
+	assert.Equal(suite.T(), int64(1), summary.UnannotatedConceptsCount)
+	assert.Equal(suite.T(), int64(0), summary.AnnotationsPerConcept.Min)
+	assert.Equal(suite.T(), int64(10), summary.AnnotationsPerConcept.P50)
+	assert.Equal(suite.T(), int64(25), summary.AnnotationsPerConcept.Max)
+}
+
+func (suite *AnnotationsCounterTestSuite) TestSummarizeEmptyDB() {
+	sc := NewSummaryCounter(suite.driver)
+	summary, err := sc.Summarize(context.Background())
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), int64(0), summary.CanonicalConceptsCount)
+	assert.Empty(suite.T(), summary.ConceptsByType)
+	assert.Equal(suite.T(), int64(0), summary.ContentCount)
+	assert.Equal(suite.T(), Distribution{}, summary.AnnotationsPerConcept)
+}
+
+func (suite *AnnotationsCounterTestSuite) addConceptLabel(conceptPrefUUID string, label string) {
+	q := &neo.Query{
+		Cypher: "MATCH (n:Concept{prefUUID: $prefUUID}) SET n:" + label,
+		Params: map[string]interface{}{"prefUUID": conceptPrefUUID},
+	}
+	err := suite.driver.Write(q)
+	require.NoError(suite.T(), err)
+}
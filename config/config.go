@@ -0,0 +1,96 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Financial-Times/neo4j-metric-aggregator/handlers"
+)
+
+// Settings are the settings which are safe to change while the service is running.
+type Settings struct {
+	// MaxRequestBatchSize is the maximum number of concepts per request.
+	MaxRequestBatchSize int
+	// RequestBudget is the overall time allowed to compute the metrics of a request.
+	RequestBudget time.Duration
+	// RateLimits are the rate limits of the clients.
+	RateLimits handlers.RateLimits
+}
+
+// Validate checks the settings, the request budget must be less than maxRequestBudget.
+func (s Settings) Validate(maxRequestBudget time.Duration) error {
+	if s.MaxRequestBatchSize < 1 {
+		return errors.New("max request batch size must be positive")
+	}
+	if s.RequestBudget <= 0 || s.RequestBudget >= maxRequestBudget {
+		return fmt.Errorf("request budget must be positive and less than %v, got %v", maxRequestBudget, s.RequestBudget)
+	}
+	if err := s.RateLimits.Validate(); err != nil {
+		return fmt.Errorf("invalid rate limits: %w", err)
+	}
+	return nil
+}
+
+// File is the content of the configuration file. The settings it sets take precedence over the flags and the
+// environment variables, the others keep their values.
+type File struct {
+	MaxRequestBatchSize *int                 `json:"maxRequestBatchSize"`
+	RequestBudget       *string              `json:"requestBudget"`
+	RateLimits          *handlers.RateLimits `json:"rateLimits"`
+}
+
+// Load reads the YAML or JSON configuration file at the given path. The unknown settings are rejected, to catch
+// the typos in their names.
+func Load(path string) (File, error) {
+	var file File
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, fmt.Errorf("failed reading config file: %w", err)
+	}
+
+	// JSON is valid YAML, the file is converted to JSON to decode it with the JSON names of the settings
+	var content interface{}
+	if err = yaml.Unmarshal(data, &content); err != nil {
+		return file, fmt.Errorf("failed parsing config file: %w", err)
+	}
+	if content == nil {
+		return file, nil
+	}
+	jsonData, err := json.Marshal(content)
+	if err != nil {
+		return file, fmt.Errorf("failed parsing config file: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return file, fmt.Errorf("failed parsing config file: %w", err)
+	}
+	return file, nil
+}
+
+// Merge returns the given settings overridden by the ones set in the file.
+func (f File) Merge(settings Settings) (Settings, error) {
+	if f.MaxRequestBatchSize != nil {
+		settings.MaxRequestBatchSize = *f.MaxRequestBatchSize
+	}
+	if f.RequestBudget != nil {
+		budget, err := time.ParseDuration(*f.RequestBudget)
+		if err != nil {
+			return settings, fmt.Errorf("invalid request budget %v", *f.RequestBudget)
+		}
+		settings.RequestBudget = budget
+	}
+	if f.RateLimits != nil {
+		settings.RateLimits = *f.RateLimits
+	}
+	return settings, nil
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Financial-Times/neo4j-metric-aggregator/handlers"
+)
+
+var testSettings = Settings{
+	MaxRequestBatchSize: 1000,
+	RequestBudget:       12 * time.Second,
+	RateLimits:          handlers.RateLimits{Default: &handlers.RateLimit{RequestsPerSecond: 1, Burst: 5}},
+}
+
+func TestLoadAndMerge(t *testing.T) {
+	tests := map[string]struct {
+		name             string
+		content          string
+		expectedSettings Settings
+	}{
+		"yaml": {
+			name: "config.yaml",
+			content: `
+maxRequestBatchSize: 500
+requestBudget: 5s
+rateLimits:
+  clients:
+    search-indexer:
+      requestsPerSecond: 50.5
+      burst: 100
+`,
+			expectedSettings: Settings{
+				MaxRequestBatchSize: 500,
+				RequestBudget:       5 * time.Second,
+				RateLimits: handlers.RateLimits{
+					Clients: map[string]handlers.RateLimit{"search-indexer": {RequestsPerSecond: 50.5, Burst: 100}},
+				},
+			},
+		},
+		"json": {
+			name:    "config.json",
+			content: `{"maxRequestBatchSize": 500}`,
+			expectedSettings: Settings{
+				MaxRequestBatchSize: 500,
+				RequestBudget:       testSettings.RequestBudget,
+				RateLimits:          testSettings.RateLimits,
+			},
+		},
+		"empty": {
+			name:             "config.yaml",
+			content:          "",
+			expectedSettings: testSettings,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), test.name)
+			require.NoError(t, os.WriteFile(path, []byte(test.content), 0600))
+
+			file, err := Load(path)
+			require.NoError(t, err)
+			settings, err := file.Merge(testSettings)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSettings, settings)
+			assert.NoError(t, settings.Validate(14*time.Second))
+		})
+	}
+}
+
+func TestLoadInvalidFile(t *testing.T) {
+	tests := map[string]struct {
+		content       string
+		expectedError string
+	}{
+		"invalid yaml": {
+			content:       "maxRequestBatchSize: [",
+			expectedError: "failed parsing config file",
+		},
+		"unknown setting": {
+			content:       "maxBatchSize: 500",
+			expectedError: `unknown field "maxBatchSize"`,
+		},
+		"invalid type": {
+			content:       "maxRequestBatchSize: lots",
+			expectedError: "failed parsing config file",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(test.content), 0600))
+
+			_, err := Load(path)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectedError)
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestMergeInvalidRequestBudget(t *testing.T) {
+	budget := "soon"
+	_, err := File{RequestBudget: &budget}.Merge(testSettings)
+	assert.EqualError(t, err, "invalid request budget soon")
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		update        func(s *Settings)
+		expectedError string
+	}{
+		"valid": {
+			update: func(s *Settings) {},
+		},
+		"zero batch size": {
+			update:        func(s *Settings) { s.MaxRequestBatchSize = 0 },
+			expectedError: "max request batch size must be positive",
+		},
+		"zero request budget": {
+			update:        func(s *Settings) { s.RequestBudget = 0 },
+			expectedError: "request budget must be positive and less than 14s, got 0s",
+		},
+		"request budget over the handlers timeout": {
+			update:        func(s *Settings) { s.RequestBudget = 14 * time.Second },
+			expectedError: "request budget must be positive and less than 14s, got 14s",
+		},
+		"invalid rate limit": {
+			update: func(s *Settings) {
+				s.RateLimits = handlers.RateLimits{Clients: map[string]handlers.RateLimit{"search-indexer": {RequestsPerSecond: 1}}}
+			},
+			expectedError: "invalid rate limits: invalid rate limit for client search-indexer",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			settings := testSettings
+			test.update(&settings)
+
+			err := settings.Validate(14 * time.Second)
+			if test.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectedError)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Financial-Times/http-handlers-go/v2/httphandlers"
+	"github.com/Financial-Times/neo4j-metric-aggregator/concept"
+	tidUtils "github.com/Financial-Times/transactionid-utils-go"
+)
+
+// metricsPath is the path of the requests for the metrics of concepts, logged by RequestLoggingHandler with the
+// details of the computation.
+const metricsPath = "/concepts/metrics"
+
+// statusRecorder records the status code of the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// metricsAccess describes a request for the metrics of concepts, filled in by GetMetrics and logged once the
+// request is served. The client ID is recorded by GetMetrics, which sees the request once authenticated.
+type metricsAccess struct {
+	clientID  string
+	batchSize int
+	found     int
+	stats     *concept.QueryStats
+}
+
+type metricsAccessKey struct{}
+
+// metricsAccessFrom returns the description of the request filled in by GetMetrics, which is not logged when the
+// request is not served by RequestLoggingHandler.
+func metricsAccessFrom(r *http.Request) *metricsAccess {
+	if access, ok := r.Context().Value(metricsAccessKey{}).(*metricsAccess); ok {
+		return access
+	}
+	return &metricsAccess{}
+}
+
+// RequestLoggingHandler wraps next, logging a single line per request. The requests for the metrics of concepts
+// are logged with the batch size, the time spent in Neo4j and the numbers of concepts found and missing, the
+// other requests with the request log of the transaction aware logging handler.
+func (h *ConceptsMetricsHandler) RequestLoggingHandler(next http.Handler) http.Handler {
+	requestLogging := httphandlers.TransactionAwareRequestLoggingHandler(h.log, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != metricsPath {
+			requestLogging.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		// the generated transaction ID is kept in the request for the handlers and the log line to share it
+		tid := tidUtils.GetTransactionIDFromRequest(r)
+		r.Header.Set(tidUtils.TransactionIDHeader, tid)
+		w.Header().Set(tidUtils.TransactionIDHeader, tid)
+		// the requests rejected before GetMetrics are logged with the client ID of their header
+		access := &metricsAccess{clientID: clientID(r)}
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), metricsAccessKey{}, access)))
+		h.logMetricsAccess(r, recorder.status, start, access)
+	})
+}
+
+// logMetricsAccess logs the line describing the request for the metrics of concepts, with the number of concepts
+// found and missing when they were computed.
+func (h *ConceptsMetricsHandler) logMetricsAccess(r *http.Request, status int, start time.Time, access *metricsAccess) {
+	entry := h.log.
+		WithField(tidUtils.TransactionIDKey, tidUtils.GetTransactionIDFromRequest(r)).
+		WithField("clientID", access.clientID).
+		WithField("method", r.Method).
+		WithField("uri", r.URL.RequestURI()).
+		WithField("status", status).
+		WithField("batchSize", access.batchSize).
+		WithField("duration", time.Since(start).String())
+	if access.stats != nil {
+		entry = entry.
+			WithField("dbDuration", access.stats.DBDuration().String()).
+			WithField("dbTransactions", access.stats.Transactions())
+	}
+	if status == http.StatusOK {
+		entry = entry.
+			WithField("found", access.found).
+			WithField("missing", access.batchSize-access.found)
+	}
+	entry.Info("concept metrics request served")
+}
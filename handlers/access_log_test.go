@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	logger "github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/neo4j-metric-aggregator/concept"
+)
+
+func TestGetMetricsAccessLog(t *testing.T) {
+	ma := new(MockMetricsAggregator)
+	ma.On("GetConceptMetrics", mock.AnythingOfType("*context.valueCtx"), testConceptsUUIDs).Return(testConcepts[:2], nil)
+
+	entry := serveAndReadAccessLog(t, ma, testQueryParam)
+
+	assert.Equal(t, "concept metrics request served", entry["msg"])
+	assert.Equal(t, "tid_access_log", entry["transaction_id"])
+	assert.Equal(t, "test-client", entry["clientID"])
+	assert.EqualValues(t, http.StatusOK, entry["status"])
+	assert.EqualValues(t, 3, entry["batchSize"])
+	assert.EqualValues(t, 2, entry["found"])
+	assert.EqualValues(t, 1, entry["missing"])
+	assert.EqualValues(t, 0, entry["dbTransactions"])
+	assert.Equal(t, "GET", entry["method"])
+	assert.Equal(t, "/concepts/metrics"+testQueryParam, entry["uri"])
+	assert.Contains(t, entry, "dbDuration")
+	assert.Contains(t, entry, "duration")
+	ma.AssertExpectations(t)
+}
+
+func TestGetMetricsAccessLogError(t *testing.T) {
+	ma := new(MockMetricsAggregator)
+	ma.On("GetConceptMetrics", mock.AnythingOfType("*context.valueCtx"), testConceptsUUIDs).Return([]concept.Concept{}, errors.New("computer says no"))
+
+	entry := serveAndReadAccessLog(t, ma, testQueryParam)
+
+	assert.EqualValues(t, http.StatusInternalServerError, entry["status"])
+	assert.EqualValues(t, 3, entry["batchSize"])
+	assert.NotContains(t, entry, "found")
+	assert.NotContains(t, entry, "missing")
+	ma.AssertExpectations(t)
+}
+
+func TestGetMetricsAccessLogInvalidRequest(t *testing.T) {
+	entry := serveAndReadAccessLog(t, new(MockMetricsAggregator), "")
+
+	assert.EqualValues(t, http.StatusBadRequest, entry["status"])
+	assert.EqualValues(t, 0, entry["batchSize"])
+}
+
+func TestGetMetricsAccessLogRejectedRequest(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+	log.Out = &out
+
+	h := NewConceptsMetricsHandler(new(MockMetricsAggregator), 10, 0, log)
+	handler := h.RequestLoggingHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil))
+
+	entries := readLogEntries(t, &out)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "concept metrics request served", entries[0]["msg"])
+	assert.EqualValues(t, http.StatusTooManyRequests, entries[0]["status"])
+	assert.NotContains(t, entries[0], "dbDuration")
+	assert.NotContains(t, entries[0], "found")
+}
+
+func TestGetMetricsAccessLogAuthenticatedClient(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+	log.Out = &out
+
+	ma := new(MockMetricsAggregator)
+	ma.On("GetConceptMetrics", mock.AnythingOfType("*context.valueCtx"), testConceptsUUIDs).Return(testConcepts[:2], nil)
+	a, err := NewAuthenticator("search-indexer:s3cr3t", "", log)
+	require.NoError(t, err)
+
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
+	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
+	req.Header.Set("X-Api-Key", "s3cr3t")
+	req.Header.Set(ClientIDHeader, "test-client")
+	h.RequestLoggingHandler(a.Handler(http.HandlerFunc(h.GetMetrics))).ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := readLogEntries(t, &out)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "search-indexer", entries[0]["clientID"])
+	assert.EqualValues(t, http.StatusOK, entries[0]["status"])
+	ma.AssertExpectations(t)
+}
+
+func TestRequestLoggingHandlerOtherRequests(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+	log.Out = &out
+
+	h := NewConceptsMetricsHandler(new(MockMetricsAggregator), 10, 0, log)
+	handler := h.RequestLoggingHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://localhost:8080/metrics/summary", nil))
+
+	entries := readLogEntries(t, &out)
+	require.Len(t, entries, 1)
+	assert.Contains(t, entries[0]["uri"], "/metrics/summary")
+	assert.NotContains(t, entries[0], "batchSize")
+}
+
+// serveAndReadAccessLog serves a metrics request with the given query and returns the fields of the only line
+// logged.
+func serveAndReadAccessLog(t *testing.T, ma *MockMetricsAggregator, query string) map[string]interface{} {
+	var out bytes.Buffer
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+	log.Out = &out
+
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
+	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+query, nil)
+	req.Header.Set("X-Request-Id", "tid_access_log")
+	req.Header.Set(ClientIDHeader, "test-client")
+	w := httptest.NewRecorder()
+	h.RequestLoggingHandler(http.HandlerFunc(h.GetMetrics)).ServeHTTP(w, req)
+	assert.Equal(t, "tid_access_log", w.Result().Header.Get("X-Request-Id"))
+
+	entries := readLogEntries(t, &out)
+	require.Len(t, entries, 1)
+	return entries[0]
+}
+
+func readLogEntries(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	var entries []map[string]interface{}
+	decoder := json.NewDecoder(out)
+	for decoder.More() {
+		var entry map[string]interface{}
+		require.NoError(t, decoder.Decode(&entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
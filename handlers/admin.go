@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"strconv"
+	"time"
+
+	log "github.com/Financial-Times/go-logger/v2"
+	tidUtils "github.com/Financial-Times/transactionid-utils-go"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminPathPrefix is the path under which the admin endpoints are served.
+const AdminPathPrefix = "/__admin"
+
+const (
+	// profileWriteMargin is the time allowed to write a CPU profile or an execution trace once collected.
+	profileWriteMargin = 15 * time.Second
+	// maxProfileDuration caps the time the write deadline is extended by, whatever the requested duration.
+	maxProfileDuration = time.Hour
+)
+
+// NewAdminHandler serves the admin endpoints under AdminPathPrefix: the log levels of the given loggers, keyed by
+// name, at /__admin/log-level, and the pprof profiles at /__admin/debug/pprof/. The handler must be protected
+// with an authenticator.
+func NewAdminHandler(loggers map[string]*log.UPPLogger, log *log.UPPLogger) http.Handler {
+	levels := &logLevelHandler{loggers: loggers, log: log}
+
+	// pprof.Index serves the profiles named after /debug/pprof/, the admin prefix is stripped for it to find them
+	adminMux := http.NewServeMux()
+	adminMux.Handle("/log-level", levels)
+	adminMux.HandleFunc("/debug/pprof/", pprof.Index)
+	adminMux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	adminMux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	adminMux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	adminMux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return http.StripPrefix(AdminPathPrefix, adminMux)
+}
+
+// ExtendProfileWriteDeadline wraps next, the admin endpoints, extending the write deadline of the server by the
+// time the CPU profile and the execution trace are collected for, since they are only written once collected.
+// It must wrap the handlers replacing the response writer, such as the request logging handler, as they hide the
+// connection from pprof, and be wrapped by the authenticator, so that the unauthenticated requests cannot keep the
+// connection open.
+func ExtendProfileWriteDeadline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if duration, ok := profileDuration(r); ok {
+			// when the deadline cannot be extended the profiles longer than the write timeout fail to be written
+			_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(duration + profileWriteMargin))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// profileDuration returns the time the requested CPU profile or execution trace is collected for, with the
+// defaults of pprof, and whether a profile or a trace is requested.
+func profileDuration(r *http.Request) (time.Duration, bool) {
+	var seconds float64
+	switch r.URL.Path {
+	case AdminPathPrefix + "/debug/pprof/profile":
+		seconds = 30
+	case AdminPathPrefix + "/debug/pprof/trace":
+		seconds = 1
+	default:
+		return 0, false
+	}
+
+	if requested, err := strconv.ParseFloat(r.URL.Query().Get("seconds"), 64); err == nil && requested > 0 {
+		seconds = requested
+	}
+	if seconds > maxProfileDuration.Seconds() {
+		return maxProfileDuration, true
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// logLevelHandler reports the levels of the loggers on GET, and changes them on PUT from a JSON object mapping
+// the names of the loggers to their new level. The levels are left unchanged when any of them is invalid.
+type logLevelHandler struct {
+	loggers map[string]*log.UPPLogger
+	log     *log.UPPLogger
+}
+
+func (h *logLevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		if err := h.setLevels(r); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "invalid_request", err.Error(), h.log)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "method_not_allowed", fmt.Sprintf("method %v is not allowed", r.Method), h.log)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(h.levels()); err != nil {
+		h.log.WithError(err).Error("Failed to write json data to response")
+	}
+}
+
+func (h *logLevelHandler) setLevels(r *http.Request) error {
+	var requested map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&requested); err != nil {
+		return fmt.Errorf("request body must be a JSON object mapping the loggers to their level: %w", err)
+	}
+
+	levels := make(map[string]logrus.Level, len(requested))
+	for name, value := range requested {
+		if _, ok := h.loggers[name]; !ok {
+			return fmt.Errorf("unknown logger %v, must be one of %v", name, h.names())
+		}
+		level, err := logrus.ParseLevel(value)
+		if err != nil {
+			return fmt.Errorf("invalid level %v for logger %v", value, name)
+		}
+		levels[name] = level
+	}
+
+	for name, level := range levels {
+		logger := h.loggers[name]
+		previous := logger.GetLevel()
+		logger.SetLevel(level)
+		h.log.WithField(tidUtils.TransactionIDKey, tidUtils.GetTransactionIDFromRequest(r)).
+			WithField("clientID", clientID(r)).
+			WithField("logger", name).
+			WithField("previousLevel", previous.String()).
+			WithField("level", level.String()).
+			Warn("log level changed")
+	}
+	return nil
+}
+
+func (h *logLevelHandler) levels() map[string]string {
+	levels := make(map[string]string, len(h.loggers))
+	for name, logger := range h.loggers {
+		levels[name] = logger.GetLevel().String()
+	}
+	return levels
+}
+
+func (h *logLevelHandler) names() []string {
+	var names []string
+	for name := range h.loggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logger "github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/http-handlers-go/v2/httphandlers"
+)
+
+func newTestAdminHandler() (http.Handler, *logger.UPPLogger, *logger.UPPLogger) {
+	appLog := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+	dbLog := logger.NewUPPLogger("test-neo4j-metric-aggregator neo4j-driver", "warning")
+	handler := NewAdminHandler(map[string]*logger.UPPLogger{"app": appLog, "neo4j-driver": dbLog}, appLog)
+	return handler, appLog, dbLog
+}
+
+func TestGetLogLevels(t *testing.T) {
+	handler, _, _ := newTestAdminHandler()
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/__admin/log-level", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"app":"info","neo4j-driver":"warning"}`, string(body))
+}
+
+func TestSetLogLevels(t *testing.T) {
+	handler, appLog, dbLog := newTestAdminHandler()
+
+	req := httptest.NewRequest("PUT", "http://localhost:8080/__admin/log-level", strings.NewReader(`{"neo4j-driver":"debug"}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"app":"info","neo4j-driver":"debug"}`, string(body))
+	assert.Equal(t, logrus.InfoLevel, appLog.GetLevel())
+	assert.Equal(t, logrus.DebugLevel, dbLog.GetLevel())
+}
+
+func TestSetInvalidLogLevels(t *testing.T) {
+	tests := map[string]struct {
+		body            string
+		expectedMessage string
+	}{
+		"unknown logger": {
+			body:            `{"app":"debug","http":"debug"}`,
+			expectedMessage: "unknown logger http, must be one of [app neo4j-driver]",
+		},
+		"invalid level": {
+			body:            `{"app":"debug","neo4j-driver":"verbose"}`,
+			expectedMessage: "invalid level verbose for logger neo4j-driver",
+		},
+		"not an object": {
+			body:            `"debug"`,
+			expectedMessage: "request body must be a JSON object mapping the loggers to their level",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			handler, appLog, dbLog := newTestAdminHandler()
+
+			req := httptest.NewRequest("PUT", "http://localhost:8080/__admin/log-level", strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Contains(t, string(body), test.expectedMessage)
+			assert.Equal(t, logrus.InfoLevel, appLog.GetLevel())
+			assert.Equal(t, logrus.WarnLevel, dbLog.GetLevel())
+		})
+	}
+}
+
+func TestLogLevelsMethodNotAllowed(t *testing.T) {
+	handler, _, _ := newTestAdminHandler()
+
+	req := httptest.NewRequest("DELETE", "http://localhost:8080/__admin/log-level", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+	assert.Equal(t, "GET, PUT", w.Result().Header.Get("Allow"))
+}
+
+func TestPprofProfileOverWriteTimeout(t *testing.T) {
+	handler, appLog, _ := newTestAdminHandler()
+	server := httptest.NewUnstartedServer(ExtendProfileWriteDeadline(httphandlers.TransactionAwareRequestLoggingHandler(appLog, handler)))
+	server.Config.WriteTimeout = 500 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/__admin/debug/pprof/profile?seconds=1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.NotEmpty(t, body)
+}
+
+func TestUnauthenticatedProfileKeepsWriteDeadline(t *testing.T) {
+	handler, appLog, _ := newTestAdminHandler()
+	a, err := NewAuthenticator("ops:s3cr3t", "", appLog)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/__admin/debug/pprof/profile?seconds=3600", nil)
+	w := &deadlineRecorder{ResponseRecorder: httptest.NewRecorder()}
+	a.Handler(ExtendProfileWriteDeadline(httphandlers.TransactionAwareRequestLoggingHandler(appLog, handler))).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+	assert.True(t, w.deadline.IsZero())
+}
+
+// deadlineRecorder records the write deadline set through the http.ResponseController.
+type deadlineRecorder struct {
+	*httptest.ResponseRecorder
+	deadline time.Time
+}
+
+func (r *deadlineRecorder) SetWriteDeadline(deadline time.Time) error {
+	r.deadline = deadline
+	return nil
+}
+
+func TestProfileDuration(t *testing.T) {
+	tests := map[string]struct {
+		url              string
+		expectedDuration time.Duration
+		expectedProfile  bool
+	}{
+		"default profile": {
+			url:              "/__admin/debug/pprof/profile",
+			expectedDuration: 30 * time.Second,
+			expectedProfile:  true,
+		},
+		"profile": {
+			url:              "/__admin/debug/pprof/profile?seconds=60",
+			expectedDuration: time.Minute,
+			expectedProfile:  true,
+		},
+		"default trace": {
+			url:              "/__admin/debug/pprof/trace?seconds=soon",
+			expectedDuration: time.Second,
+			expectedProfile:  true,
+		},
+		"trace": {
+			url:              "/__admin/debug/pprof/trace?seconds=0.5",
+			expectedDuration: 500 * time.Millisecond,
+			expectedProfile:  true,
+		},
+		"capped": {
+			url:              "/__admin/debug/pprof/profile?seconds=1e12",
+			expectedDuration: time.Hour,
+			expectedProfile:  true,
+		},
+		"other endpoint": {
+			url: "/__admin/debug/pprof/heap?seconds=60",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			duration, ok := profileDuration(httptest.NewRequest("GET", "http://localhost:8080"+test.url, nil))
+			assert.Equal(t, test.expectedProfile, ok)
+			assert.Equal(t, test.expectedDuration, duration)
+		})
+	}
+}
+
+func TestPprofIndex(t *testing.T) {
+	handler, _, _ := newTestAdminHandler()
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/__admin/debug/pprof/goroutine?debug=1", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "goroutine profile")
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Financial-Times/go-logger/v2"
+	tidUtils "github.com/Financial-Times/transactionid-utils-go"
+)
+
+const apiKeyHeader = "X-Api-Key"
+
+// Authenticator rejects the requests without a valid API key, given either in the X-Api-Key header or as a
+// bearer token in the Authorization header. The keys are set with the keys string and in the keys file, which is
+// reloaded when it changes.
+type Authenticator struct {
+	path string
+	log  *log.UPPLogger
+
+	mu         sync.RWMutex
+	staticKeys map[[sha256.Size]byte]string
+	fileKeys   map[[sha256.Size]byte]string
+	modTime    time.Time
+	size       int64
+}
+
+// NewAuthenticator creates an authenticator accepting the API keys in the given comma or newline separated list
+// and in the file at the given path, if any. Each key is given as clientID:key, to identify the client making the
+// requests, or as a bare key.
+func NewAuthenticator(keys string, path string, log *log.UPPLogger) (*Authenticator, error) {
+	staticKeys, err := parseAPIKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Authenticator{path: path, log: log, staticKeys: staticKeys}
+	if path != "" {
+		if _, err = a.Reload(); err != nil {
+			return nil, err
+		}
+	}
+	if len(a.staticKeys) == 0 && len(a.fileKeys) == 0 {
+		return nil, errors.New("no API keys configured")
+	}
+	return a, nil
+}
+
+// Reload reads the keys file again if it has changed since it was last read, and reports whether it did.
+// The previous keys are kept when the file cannot be read or parsed.
+func (a *Authenticator) Reload() (bool, error) {
+	if a.path == "" {
+		return false, nil
+	}
+
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return false, fmt.Errorf("failed reading API keys file: %w", err)
+	}
+
+	a.mu.RLock()
+	unchanged := info.ModTime().Equal(a.modTime) && info.Size() == a.size
+	a.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return false, fmt.Errorf("failed reading API keys file: %w", err)
+	}
+	keys, err := parseAPIKeys(string(data))
+	if err != nil {
+		return false, fmt.Errorf("invalid API keys file: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.fileKeys = keys
+	a.modTime = info.ModTime()
+	a.size = info.Size()
+	return true, nil
+}
+
+// WatchFile reloads the keys file every interval until ctx is done.
+func (a *Authenticator) WatchFile(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := a.Reload()
+			if err != nil {
+				a.log.WithError(err).Error("Failed reloading API keys, the previous keys are still in use")
+				continue
+			}
+			if reloaded {
+				a.log.Info("Reloaded API keys")
+			}
+		}
+	}
+}
+
+// authenticate returns the client ID of the key with the given hash, and whether the key is valid.
+func (a *Authenticator) authenticate(hash [sha256.Size]byte) (string, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if client, ok := a.staticKeys[hash]; ok {
+		return client, true
+	}
+	client, ok := a.fileKeys[hash]
+	return client, ok
+}
+
+// Handler wraps next, responding with 401 Unauthorized to the requests without a valid API key. The client ID
+// of the key, if any, identifies the client of the authenticated requests. The requests authenticated with a key
+// without client ID are rate limited by a short hash of the key.
+func (a *Authenticator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hash := sha256.Sum256([]byte(requestAPIKey(r)))
+		client, ok := a.authenticate(hash)
+		if !ok {
+			a.log.WithField(tidUtils.TransactionIDKey, tidUtils.GetTransactionIDFromRequest(r)).
+				WithField("path", r.URL.Path).
+				Warn("rejecting request without a valid API key")
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.Header().Set("Content-Type", "application/json")
+			writeErrorResponse(w, http.StatusUnauthorized, "unauthorized", "missing or invalid API key", a.log)
+			return
+		}
+
+		if client != "" {
+			r = r.WithContext(context.WithValue(r.Context(), clientIDContextKey{}, client))
+		} else {
+			r = r.WithContext(context.WithValue(r.Context(), apiKeyIDContextKey{}, "api-key:"+hex.EncodeToString(hash[:8])))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return key
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// parseAPIKeys parses the comma or newline separated clientID:key or key entries, ignoring the blank lines and
+// the lines starting with #. The keys are stored hashed to look them up in constant time.
+func parseAPIKeys(data string) (map[[sha256.Size]byte]string, error) {
+	keys := make(map[[sha256.Size]byte]string)
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, entry := range strings.Split(line, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+
+			client, key, hasClient := strings.Cut(entry, ":")
+			if !hasClient {
+				client, key = "", entry
+			}
+			client, key = strings.TrimSpace(client), strings.TrimSpace(key)
+			if key == "" || (hasClient && client == "") {
+				return nil, errors.New("API keys must be given as clientID:key or key")
+			}
+			keys[sha256.Sum256([]byte(key))] = client
+		}
+	}
+	return keys, nil
+}
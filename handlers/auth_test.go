@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logger "github.com/Financial-Times/go-logger/v2"
+)
+
+func newTestAuthHandler(t *testing.T, keys string, path string) (http.Handler, *Authenticator, *string) {
+	a, err := NewAuthenticator(keys, path, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	require.NoError(t, err)
+
+	var client string
+	handler := a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client = clientID(r)
+		w.WriteHeader(http.StatusOK)
+	}))
+	return handler, a, &client
+}
+
+func TestAuthenticator(t *testing.T) {
+	handler, _, client := newTestAuthHandler(t, "search-indexer:s3cr3t, 4n0th3r-s3cr3t", "")
+
+	tests := map[string]struct {
+		headers        map[string]string
+		expectedStatus int
+		expectedClient string
+	}{
+		"api key header": {
+			headers:        map[string]string{"X-Api-Key": "s3cr3t"},
+			expectedStatus: http.StatusOK,
+			expectedClient: "search-indexer",
+		},
+		"bearer token": {
+			headers:        map[string]string{"Authorization": "Bearer s3cr3t"},
+			expectedStatus: http.StatusOK,
+			expectedClient: "search-indexer",
+		},
+		"authenticated client id takes precedence": {
+			headers:        map[string]string{"X-Api-Key": "s3cr3t", "X-Client-ID": "editorial-dashboard"},
+			expectedStatus: http.StatusOK,
+			expectedClient: "search-indexer",
+		},
+		"key without client id": {
+			headers:        map[string]string{"X-Api-Key": "4n0th3r-s3cr3t", "X-Client-ID": "editorial-dashboard"},
+			expectedStatus: http.StatusOK,
+			expectedClient: "editorial-dashboard",
+		},
+		"invalid key": {
+			headers:        map[string]string{"X-Api-Key": "search-indexer:s3cr3t"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"basic auth": {
+			headers:        map[string]string{"Authorization": "Basic czNjcjN0"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"missing key": {
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			*client = ""
+			req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+			resp := w.Result()
+
+			assert.Equal(t, test.expectedStatus, resp.StatusCode)
+			assert.Equal(t, test.expectedClient, *client)
+			if test.expectedStatus == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", resp.Header.Get("WWW-Authenticate"))
+				body, err := ioutil.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.JSONEq(t, `{"message":"missing or invalid API key","code":"unauthorized"}`, string(body))
+			}
+		})
+	}
+}
+
+func TestAuthenticatorReloadsKeysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-keys")
+	require.NoError(t, os.WriteFile(path, []byte("# consumers of the service\nsearch-indexer:s3cr3t\n\n"), 0600))
+
+	handler, a, _ := newTestAuthHandler(t, "", path)
+	status := func(key string) int {
+		req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
+		req.Header.Set("X-Api-Key", key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Result().StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, status("s3cr3t"))
+	reloaded, err := a.Reload()
+	assert.NoError(t, err)
+	assert.False(t, reloaded)
+
+	require.NoError(t, os.WriteFile(path, []byte("search-indexer:n3w-s3cr3t\n"), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	reloaded, err = a.Reload()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, http.StatusUnauthorized, status("s3cr3t"))
+	assert.Equal(t, http.StatusOK, status("n3w-s3cr3t"))
+
+	require.NoError(t, os.WriteFile(path, []byte(":invalid\n"), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)))
+	_, err = a.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusOK, status("n3w-s3cr3t"), "the previous keys are kept when the file is invalid")
+}
+
+func TestNewAuthenticatorErrors(t *testing.T) {
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	_, err := NewAuthenticator("", "", log)
+	assert.Error(t, err)
+
+	_, err = NewAuthenticator("search-indexer:", "", log)
+	assert.Error(t, err)
+
+	_, err = NewAuthenticator("", filepath.Join(t.TempDir(), "missing"), log)
+	assert.Error(t, err)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import "net/http"
+
+// ClientIDHeader identifies the consumer of the service making a request.
+const ClientIDHeader = "X-Client-ID"
+
+type clientIDContextKey struct{}
+
+type apiKeyIDContextKey struct{}
+
+// clientID returns the identifier of the consumer making the request, empty when unknown. The client ID of the
+// API key the request is authenticated with takes precedence over the X-Client-ID header.
+func clientID(r *http.Request) string {
+	if client, ok := r.Context().Value(clientIDContextKey{}).(string); ok {
+		return client
+	}
+	return r.Header.Get(ClientIDHeader)
+}
+
+// rateLimitKey returns the key the requests are rate limited by. The authenticated requests are limited by the
+// client ID of their API key, or by the key itself when it has none, whatever their X-Client-ID header.
+func rateLimitKey(r *http.Request) string {
+	if client, ok := r.Context().Value(clientIDContextKey{}).(string); ok {
+		return client
+	}
+	if keyID, ok := r.Context().Value(apiKeyIDContextKey{}).(string); ok {
+		return keyID
+	}
+	return r.Header.Get(ClientIDHeader)
+}
@@ -6,76 +6,304 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	log "github.com/Financial-Times/go-logger/v2"
 	"github.com/Financial-Times/neo4j-metric-aggregator/concept"
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
+	"github.com/Financial-Times/neo4j-metric-aggregator/tracing"
 	tidUtils "github.com/Financial-Times/transactionid-utils-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Financial-Times/neo4j-metric-aggregator/handlers")
+
+// bookmarkHeader carries the Neo4j bookmarks the reads must be consistent with in the requests, and the
+// bookmark of the last executed read in the responses.
+const bookmarkHeader = "X-Neo4j-Bookmark"
+
+const (
+	defaultOrphansLimit = 100
+	maxOrphansLimit     = 1000
 )
 
 type ConceptsMetricsHandler struct {
 	metricsAggregator concept.MetricsAggregator
-	maxUUIDBatchSize  int
+	maxUUIDBatchSize  atomic.Int64
+	requestBudget     atomic.Int64
 	log               *log.UPPLogger
 }
 
-func NewConceptsMetricsHandler(metricsAggregator concept.MetricsAggregator, maxUUIDBatchSize int, log *log.UPPLogger) *ConceptsMetricsHandler {
-	return &ConceptsMetricsHandler{
+// NewConceptsMetricsHandler creates a handler computing the metrics of each request within requestBudget.
+// The requests have no deadline when requestBudget is zero.
+func NewConceptsMetricsHandler(metricsAggregator concept.MetricsAggregator, maxUUIDBatchSize int, requestBudget time.Duration, log *log.UPPLogger) *ConceptsMetricsHandler {
+	h := &ConceptsMetricsHandler{
 		metricsAggregator: metricsAggregator,
-		maxUUIDBatchSize:  maxUUIDBatchSize,
 		log:               log,
 	}
+	h.SetLimits(maxUUIDBatchSize, requestBudget)
+	return h
+}
+
+// SetLimits changes the maximum number of concepts per request and the request budget, for the requests received
+// from now on.
+func (h *ConceptsMetricsHandler) SetLimits(maxUUIDBatchSize int, requestBudget time.Duration) {
+	h.maxUUIDBatchSize.Store(int64(maxUUIDBatchSize))
+	h.requestBudget.Store(int64(requestBudget))
 }
 
 func (h *ConceptsMetricsHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
-	tid := tidUtils.GetTransactionIDFromRequest(r)
-	ctx := tidUtils.TransactionAwareContext(context.Background(), tid)
+	access := metricsAccessFrom(r)
+	access.clientID = clientID(r)
+
+	ctx, cancel := h.newRequestContext(r)
+	defer cancel()
+	ctx, access.stats = concept.WithQueryStats(ctx)
+
+	var err error
+	ctx, span := tracer.Start(ctx, "GetMetrics",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String(tidUtils.TransactionIDKey, tidUtils.GetTransactionIDFromRequest(r))))
+	defer func() { tracing.EndSpan(span, err) }()
 
 	w.Header().Add("Content-Type", "application/json")
 
 	uuids, err := h.extractConceptUUIDs(r)
 	if err != nil {
-		h.writeJSONError(w, err, http.StatusBadRequest)
+		h.writeJSONError(w, concept.WithKind(concept.ErrValidation, err))
+		return
+	}
+	access.batchSize = len(uuids)
+
+	csvFormat, err := wantsCSV(r)
+	if err != nil {
+		h.writeJSONError(w, concept.WithKind(concept.ErrValidation, err))
 		return
 	}
 
+	profile, err := extractProfileParam(r, csvFormat)
+	if err != nil {
+		h.writeJSONError(w, concept.WithKind(concept.ErrValidation, err))
+		return
+	}
+	if profile {
+		ctx = concept.WithProfiling(ctx)
+	}
+
 	concepts, err := h.metricsAggregator.GetConceptMetrics(ctx, uuids)
 	if err != nil {
-		h.writeJSONError(w, err, http.StatusInternalServerError)
+		h.writeJSONError(w, err)
+		return
+	}
+	access.found = len(concepts)
+	setBookmarkHeader(w, ctx)
+
+	if profile {
+		report := profiledConcepts{Concepts: concepts, Profiles: concept.Profiles(ctx)}
+		if err = json.NewEncoder(w).Encode(&report); err != nil {
+			h.writeJSONError(w, err)
+		}
+		return
+	}
+
+	if csvFormat {
+		var rows [][]string
+		for _, c := range concepts {
+			rows = append(rows, c.CSVRecord())
+		}
+		if err = writeCSV(w, concept.ConceptCSVHeader, rows); err != nil {
+			h.log.WithError(err).Error("Failed to write csv data to response")
+		}
 		return
 	}
 
 	if err = json.NewEncoder(w).Encode(&concepts); err != nil {
-		h.writeJSONError(w, err, http.StatusInternalServerError)
+		h.writeJSONError(w, err)
+		return
+	}
+}
+
+func (h *ConceptsMetricsHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := h.newRequestContext(r)
+	defer cancel()
+
+	w.Header().Add("Content-Type", "application/json")
+
+	summary, err := h.metricsAggregator.GetSummary(ctx)
+	if err != nil {
+		h.writeJSONError(w, err)
+		return
+	}
+	setBookmarkHeader(w, ctx)
+
+	if err = json.NewEncoder(w).Encode(&summary); err != nil {
+		h.writeJSONError(w, err)
+		return
+	}
+}
+
+func (h *ConceptsMetricsHandler) GetOrphans(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := h.newRequestContext(r)
+	defer cancel()
+
+	w.Header().Add("Content-Type", "application/json")
+
+	kind, since, offset, limit, err := h.extractOrphansParams(r)
+	if err != nil {
+		h.writeJSONError(w, concept.WithKind(concept.ErrValidation, err))
+		return
+	}
+	csvFormat, err := wantsCSV(r)
+	if err != nil {
+		h.writeJSONError(w, concept.WithKind(concept.ErrValidation, err))
+		return
+	}
+
+	report, err := h.metricsAggregator.GetOrphans(ctx, kind, since, offset, limit)
+	if err != nil {
+		h.writeJSONError(w, err)
+		return
+	}
+	setBookmarkHeader(w, ctx)
+
+	if csvFormat {
+		var rows [][]string
+		for _, c := range report.Concepts {
+			rows = append(rows, []string{c.UUID, c.PrefLabel, strings.Join(c.Types, ";")})
+		}
+		if err = writeCSV(w, []string{"uuid", "prefLabel", "types"}, rows); err != nil {
+			h.log.WithError(err).Error("Failed to write csv data to response")
+		}
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(&report); err != nil {
+		h.writeJSONError(w, err)
 		return
 	}
 }
 
+func (h *ConceptsMetricsHandler) extractOrphansParams(r *http.Request) (kind concept.OrphanKind, since int64, offset int, limit int, err error) {
+	query := r.URL.Query()
+
+	kind = concept.OrphanKind(query.Get("kind"))
+	if !isValidOrphanKind(kind) {
+		return "", 0, 0, 0, fmt.Errorf("kind URL query parameter must be one of %v", concept.OrphanKinds)
+	}
+
+	if s := query.Get("since"); s != "" {
+		if kind != concept.OrphanKindUnannotated {
+			return "", 0, 0, 0, fmt.Errorf("since URL query parameter is supported only for kind %v", concept.OrphanKindUnannotated)
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return "", 0, 0, 0, errors.New("since URL query parameter must be an RFC3339 timestamp")
+		}
+		since = t.Unix()
+	}
+
+	offset, err = intQueryParam(query.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		return "", 0, 0, 0, errors.New("offset URL query parameter must be a non-negative integer")
+	}
+
+	limit, err = intQueryParam(query.Get("limit"), defaultOrphansLimit)
+	if err != nil || limit < 1 || limit > maxOrphansLimit {
+		return "", 0, 0, 0, fmt.Errorf("limit URL query parameter must be an integer between 1 and %v", maxOrphansLimit)
+	}
+
+	return kind, since, offset, limit, nil
+}
+
+func isValidOrphanKind(kind concept.OrphanKind) bool {
+	for _, k := range concept.OrphanKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func intQueryParam(value string, defaultValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
 func (h *ConceptsMetricsHandler) extractConceptUUIDs(r *http.Request) ([]string, error) {
 	commaSeparatedUUIDs := r.URL.Query().Get("uuids")
 	if commaSeparatedUUIDs == "" {
 		return nil, errors.New("uuids URL query parameter is missing or empty")
 	}
 	uuids := strings.Split(commaSeparatedUUIDs, ",")
-	if len(uuids) > h.maxUUIDBatchSize {
-		return nil, fmt.Errorf("max concept UUIDs batch size is %v", h.maxUUIDBatchSize)
+	if maxUUIDBatchSize := int(h.maxUUIDBatchSize.Load()); len(uuids) > maxUUIDBatchSize {
+		return nil, fmt.Errorf("max concept UUIDs batch size is %v", maxUUIDBatchSize)
 	}
 	return uuids, nil
 }
 
-func (h *ConceptsMetricsHandler) writeJSONError(w http.ResponseWriter, err error, status int) {
-	w.WriteHeader(status)
+// profiledConcepts is the response to the metrics requests with profile=true, adding the profiles of the queries
+// counting the annotations of every concept to the metrics.
+type profiledConcepts struct {
+	Concepts []concept.Concept      `json:"concepts"`
+	Profiles []concept.QueryProfile `json:"profiles"`
+}
 
-	message := make(map[string]interface{})
-	message["message"] = err.Error()
-	j, err := json.Marshal(&message)
+// extractProfileParam reports whether the queries of the request must be profiled. Profiling is only supported
+// for the JSON responses.
+func extractProfileParam(r *http.Request, csvFormat bool) (bool, error) {
+	value := r.URL.Query().Get("profile")
+	if value == "" {
+		return false, nil
+	}
+	profile, err := strconv.ParseBool(value)
 	if err != nil {
-		h.log.WithError(err).Error("Failed to parse provided message to json, this is a bug.")
-		return
+		return false, errors.New("profile URL query parameter must be a boolean")
+	}
+	if profile && csvFormat {
+		return false, errors.New("profile URL query parameter is not supported with the CSV format")
 	}
+	return profile, nil
+}
 
-	if _, err := w.Write(j); err != nil {
-		h.log.WithError(err).Error("Failed to write json data to response")
-		return
+// newRequestContext returns a context carrying the transaction ID and the Neo4j bookmarks of the request, with
+// a deadline set by the request budget.
+func (h *ConceptsMetricsHandler) newRequestContext(r *http.Request) (context.Context, context.CancelFunc) {
+	tid := tidUtils.GetTransactionIDFromRequest(r)
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(r.Context()))
+	ctx = tidUtils.TransactionAwareContext(ctx, tid)
+
+	var bookmarks []string
+	for _, value := range r.Header.Values(bookmarkHeader) {
+		for _, bookmark := range strings.Split(value, ",") {
+			if bookmark = strings.TrimSpace(bookmark); bookmark != "" {
+				bookmarks = append(bookmarks, bookmark)
+			}
+		}
+	}
+	ctx = neo.WithBookmarks(ctx, bookmarks)
+
+	if budget := time.Duration(h.requestBudget.Load()); budget > 0 {
+		return context.WithTimeout(ctx, budget)
 	}
+	return context.WithCancel(ctx)
+}
+
+func setBookmarkHeader(w http.ResponseWriter, ctx context.Context) {
+	if bookmark := neo.LastBookmark(ctx); bookmark != "" {
+		w.Header().Set(bookmarkHeader, bookmark)
+	}
+}
+
+// writeJSONError responds with the message of the error and the status and code mapped to its kind.
+func (h *ConceptsMetricsHandler) writeJSONError(w http.ResponseWriter, err error) {
+	status, code := errorStatus(err)
+	setRetryAfterHeader(w, err)
+	writeErrorResponse(w, status, code, err.Error(), h.log)
 }
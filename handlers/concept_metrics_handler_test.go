@@ -3,16 +3,26 @@ package handlers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 
 	logger "github.com/Financial-Times/go-logger/v2"
 	"github.com/Financial-Times/neo4j-metric-aggregator/concept"
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
+	"github.com/Financial-Times/neo4j-metric-aggregator/tracing"
 )
 
 var testConceptsUUIDs = []string{
@@ -21,42 +31,59 @@ var testConceptsUUIDs = []string{
 	"e25c0e2c-e275-403b-8fd8-9f079634cae9",
 }
 
+var (
+	testFirstAnnotatedAt = "2019-03-11T09:21:45Z"
+	testLastAnnotatedAt  = "2021-10-04T16:02:11Z"
+)
+
 var testConcepts = []concept.Concept{
 	{
 		UUID:    testConceptsUUIDs[0],
-		Metrics: concept.Metrics{AnnotationsCount: 1, PrevWeekAnnotationsCount: 2},
+		Metrics: concept.Metrics{AnnotationsCount: 1, PrevWeekAnnotationsCount: 2, FirstAnnotatedAt: &testFirstAnnotatedAt, LastAnnotatedAt: &testLastAnnotatedAt},
 	},
 	{
 		UUID:    testConceptsUUIDs[1],
-		Metrics: concept.Metrics{AnnotationsCount: 123, PrevWeekAnnotationsCount: 1024},
+		Metrics: concept.Metrics{AnnotationsCount: 123, PrevWeekAnnotationsCount: 1024, FirstAnnotatedAt: &testFirstAnnotatedAt, LastAnnotatedAt: &testLastAnnotatedAt},
 	},
 	{
 		UUID:    testConceptsUUIDs[2],
-		Metrics: concept.Metrics{AnnotationsCount: 12, PrevWeekAnnotationsCount: 52},
+		Metrics: concept.Metrics{AnnotationsCount: 12, PrevWeekAnnotationsCount: 52, FirstAnnotatedAt: &testFirstAnnotatedAt, LastAnnotatedAt: &testLastAnnotatedAt},
 	},
 }
 
+// testUnannotatedConcept has never been annotated, so it has no annotation timestamps.
+var testUnannotatedConcept = concept.Concept{
+	UUID:    "5c1a7d6b-2f7e-4f57-9a55-1c2a6f0c4e01",
+	Metrics: concept.Metrics{AnnotationsCount: 0, PrevWeekAnnotationsCount: 0},
+}
+
 const testJSONPayload = `
 [
   {
     "uuid": "38ea6443-050e-4d02-9564-537490f84abd",
     "metrics": {
 	  "annotationsCount": 1,
-	  "prevWeekAnnotationsCount": 2
+	  "prevWeekAnnotationsCount": 2,
+	  "firstAnnotatedAt": "2019-03-11T09:21:45Z",
+	  "lastAnnotatedAt": "2021-10-04T16:02:11Z"
     }
   },
   {
     "uuid": "a4de0e8f-96f4-4ccf-ba26-410f005e021b",
     "metrics": {
       "annotationsCount": 123,
-	  "prevWeekAnnotationsCount": 1024
+	  "prevWeekAnnotationsCount": 1024,
+	  "firstAnnotatedAt": "2019-03-11T09:21:45Z",
+	  "lastAnnotatedAt": "2021-10-04T16:02:11Z"
     }
   },
   {
     "uuid": "e25c0e2c-e275-403b-8fd8-9f079634cae9",
     "metrics": {
       "annotationsCount": 12,
-	  "prevWeekAnnotationsCount": 52
+	  "prevWeekAnnotationsCount": 52,
+	  "firstAnnotatedAt": "2019-03-11T09:21:45Z",
+	  "lastAnnotatedAt": "2021-10-04T16:02:11Z"
     }
   }
 ]
@@ -70,7 +97,7 @@ func TestHappyGetMetrics(t *testing.T) {
 
 	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
 
-	h := NewConceptsMetricsHandler(ma, 10, log)
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
 	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
 	w := httptest.NewRecorder()
 
@@ -87,11 +114,213 @@ func TestHappyGetMetrics(t *testing.T) {
 	ma.AssertExpectations(t)
 }
 
+func TestGetMetricsWithBookmarks(t *testing.T) {
+	expectedBookmarks := []string{"FB:kcwQy5wl3dMdQ4eRk1ErFBThOpA=kA", "FB:kcwQ1bwl3dMdQ4eRk1ErFBThOpA=gA", "FB:kcwQ0Nwl3dMdQ4eRk1ErFBThOpA=mA"}
+	hasBookmarks := mock.MatchedBy(func(ctx context.Context) bool {
+		return assert.ObjectsAreEqual(expectedBookmarks, neo.Bookmarks(ctx))
+	})
+
+	ma := new(MockMetricsAggregator)
+	ma.On("GetConceptMetrics", hasBookmarks, testConceptsUUIDs).Return(testConcepts, nil)
+
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
+	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
+	req.Header.Add("X-Neo4j-Bookmark", "FB:kcwQy5wl3dMdQ4eRk1ErFBThOpA=kA, FB:kcwQ1bwl3dMdQ4eRk1ErFBThOpA=gA")
+	req.Header.Add("X-Neo4j-Bookmark", "FB:kcwQ0Nwl3dMdQ4eRk1ErFBThOpA=mA")
+	w := httptest.NewRecorder()
+
+	h.GetMetrics(w, req)
+	resp := w.Result()
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	ma.AssertExpectations(t)
+}
+
+func TestGetMetricsCSV(t *testing.T) {
+	tests := map[string]struct {
+		url    string
+		accept string
+	}{
+		"format query parameter": {
+			url: "http://localhost:8080/concepts/metrics" + testQueryParam + "&format=csv",
+		},
+		"accept header": {
+			url:    "http://localhost:8080/concepts/metrics" + testQueryParam,
+			accept: "text/csv",
+		},
+		"accept header with alternatives": {
+			url:    "http://localhost:8080/concepts/metrics" + testQueryParam,
+			accept: "text/csv;q=0.9, application/json;q=0.8",
+		},
+		"accept header preferring csv over a wildcard": {
+			url:    "http://localhost:8080/concepts/metrics" + testQueryParam,
+			accept: "*/*, text/csv",
+		},
+		"accept header with an unsupported preferred type": {
+			url:    "http://localhost:8080/concepts/metrics" + testQueryParam,
+			accept: "text/html, text/csv;q=0.5",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ma := new(MockMetricsAggregator)
+			ma.On("GetConceptMetrics", mock.AnythingOfType("*context.valueCtx"), testConceptsUUIDs).Return(testConcepts, nil)
+
+			log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+			h := NewConceptsMetricsHandler(ma, 10, 0, log)
+			req := httptest.NewRequest("GET", test.url, nil)
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+			w := httptest.NewRecorder()
+
+			h.GetMetrics(w, req)
+			resp := w.Result()
+
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+			actualBody, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, "uuid,annotationsCount,prevWeekAnnotationsCount,firstAnnotatedAt,lastAnnotatedAt\n"+
+				"38ea6443-050e-4d02-9564-537490f84abd,1,2,2019-03-11T09:21:45Z,2021-10-04T16:02:11Z\n"+
+				"a4de0e8f-96f4-4ccf-ba26-410f005e021b,123,1024,2019-03-11T09:21:45Z,2021-10-04T16:02:11Z\n"+
+				"e25c0e2c-e275-403b-8fd8-9f079634cae9,12,52,2019-03-11T09:21:45Z,2021-10-04T16:02:11Z\n", string(actualBody))
+
+			ma.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetMetricsUnannotatedConcept(t *testing.T) {
+	ma := new(MockMetricsAggregator)
+	ma.On("GetConceptMetrics", mock.AnythingOfType("*context.valueCtx"), []string{testUnannotatedConcept.UUID}).Return([]concept.Concept{testUnannotatedConcept}, nil)
+
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics?uuids="+testUnannotatedConcept.UUID, nil)
+	w := httptest.NewRecorder()
+	h.GetMetrics(w, req)
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	actualJSONBody, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"uuid":"5c1a7d6b-2f7e-4f57-9a55-1c2a6f0c4e01","metrics":{"annotationsCount":0,"prevWeekAnnotationsCount":0,"firstAnnotatedAt":null,"lastAnnotatedAt":null}}]`, string(actualJSONBody))
+
+	req = httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics?format=csv&uuids="+testUnannotatedConcept.UUID, nil)
+	w = httptest.NewRecorder()
+	h.GetMetrics(w, req)
+	resp = w.Result()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	actualBody, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "uuid,annotationsCount,prevWeekAnnotationsCount,firstAnnotatedAt,lastAnnotatedAt\n"+
+		"5c1a7d6b-2f7e-4f57-9a55-1c2a6f0c4e01,0,0,,\n", string(actualBody))
+
+	ma.AssertExpectations(t)
+}
+
+func TestGetMetricsFormatJSONOverridesAcceptHeader(t *testing.T) {
+	ma := new(MockMetricsAggregator)
+	ma.On("GetConceptMetrics", mock.AnythingOfType("*context.valueCtx"), testConceptsUUIDs).Return(testConcepts, nil)
+
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
+	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam+"&format=json", nil)
+	req.Header.Set("Accept", "text/csv")
+	w := httptest.NewRecorder()
+
+	h.GetMetrics(w, req)
+	resp := w.Result()
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	actualJSONBody, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, testJSONPayload, string(actualJSONBody))
+
+	ma.AssertExpectations(t)
+}
+
+func TestGetMetricsAcceptHeaderPreferringJSON(t *testing.T) {
+	tests := map[string]string{
+		"csv not acceptable":            "text/csv;q=0",
+		"json preferred":                "application/json, text/csv;q=0.1",
+		"wildcard preferred":            "text/csv;q=0.5, */*",
+		"json and csv of the same rank": "application/json, text/csv",
+		"wildcard only":                 "*/*",
+	}
+
+	for name, accept := range tests {
+		t.Run(name, func(t *testing.T) {
+			ma := new(MockMetricsAggregator)
+			ma.On("GetConceptMetrics", mock.AnythingOfType("*context.valueCtx"), testConceptsUUIDs).Return(testConcepts, nil)
+
+			log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+			h := NewConceptsMetricsHandler(ma, 10, 0, log)
+			req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
+			req.Header.Set("Accept", accept)
+			w := httptest.NewRecorder()
+
+			h.GetMetrics(w, req)
+			resp := w.Result()
+
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			actualJSONBody, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.JSONEq(t, testJSONPayload, string(actualJSONBody))
+
+			ma.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetMetricsUnknownFormat(t *testing.T) {
+	ma := new(MockMetricsAggregator)
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
+	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam+"&format=xml", nil)
+	w := httptest.NewRecorder()
+
+	h.GetMetrics(w, req)
+	resp := w.Result()
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	actualJSONBody, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"message":"format URL query parameter must be either json or csv","code":"invalid_request"}`, string(actualJSONBody))
+
+	ma.AssertExpectations(t)
+}
+
 func TestGetMetricsMissingUUIDsQueryParam(t *testing.T) {
 	ma := new(MockMetricsAggregator)
 	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
 
-	h := NewConceptsMetricsHandler(ma, 10, log)
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
 	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics", nil)
 	w := httptest.NewRecorder()
 
@@ -103,7 +332,7 @@ func TestGetMetricsMissingUUIDsQueryParam(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 	actualJSONBody, err := ioutil.ReadAll(resp.Body)
 	assert.NoError(t, err)
-	assert.JSONEq(t, `{"message":"uuids URL query parameter is missing or empty"}`, string(actualJSONBody))
+	assert.JSONEq(t, `{"message":"uuids URL query parameter is missing or empty","code":"invalid_request"}`, string(actualJSONBody))
 
 	ma.AssertExpectations(t)
 }
@@ -112,7 +341,7 @@ func TestGetMetricsEmptyUUIDsQueryParam(t *testing.T) {
 	ma := new(MockMetricsAggregator)
 	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
 
-	h := NewConceptsMetricsHandler(ma, 10, log)
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
 	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics?uuids=", nil)
 	w := httptest.NewRecorder()
 
@@ -124,7 +353,7 @@ func TestGetMetricsEmptyUUIDsQueryParam(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 	actualJSONBody, err := ioutil.ReadAll(resp.Body)
 	assert.NoError(t, err)
-	assert.JSONEq(t, `{"message":"uuids URL query parameter is missing or empty"}`, string(actualJSONBody))
+	assert.JSONEq(t, `{"message":"uuids URL query parameter is missing or empty","code":"invalid_request"}`, string(actualJSONBody))
 
 	ma.AssertExpectations(t)
 }
@@ -133,7 +362,7 @@ func TestUUIDsBatchLimit(t *testing.T) {
 	ma := new(MockMetricsAggregator)
 	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
 
-	h := NewConceptsMetricsHandler(ma, 2, log)
+	h := NewConceptsMetricsHandler(ma, 2, 0, log)
 	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
 	w := httptest.NewRecorder()
 
@@ -145,8 +374,24 @@ func TestUUIDsBatchLimit(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 	actualJSONBody, err := ioutil.ReadAll(resp.Body)
 	assert.NoError(t, err)
-	assert.JSONEq(t, `{"message":"max concept UUIDs batch size is 2"}`, string(actualJSONBody))
+	assert.JSONEq(t, `{"message":"max concept UUIDs batch size is 2","code":"invalid_request"}`, string(actualJSONBody))
+
+	ma.AssertExpectations(t)
+}
 
+func TestSetLimits(t *testing.T) {
+	ma := new(MockMetricsAggregator)
+	ma.On("GetConceptMetrics", mock.AnythingOfType("*context.valueCtx"), testConceptsUUIDs).Return(testConcepts, nil)
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	h := NewConceptsMetricsHandler(ma, 2, 0, log)
+	h.SetLimits(3, time.Second)
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
+	w := httptest.NewRecorder()
+	h.GetMetrics(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
 	ma.AssertExpectations(t)
 }
 
@@ -156,7 +401,7 @@ func TestMetricsAggregatorError(t *testing.T) {
 
 	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
 
-	h := NewConceptsMetricsHandler(ma, 10, log)
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
 	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
 	w := httptest.NewRecorder()
 
@@ -168,11 +413,423 @@ func TestMetricsAggregatorError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
 	actualJSONBody, err := ioutil.ReadAll(resp.Body)
 	assert.NoError(t, err)
-	assert.JSONEq(t, `{"message":"computer says no"}`, string(actualJSONBody))
+	assert.JSONEq(t, `{"message":"computer says no","code":"internal_error"}`, string(actualJSONBody))
 
 	ma.AssertExpectations(t)
 }
 
+func TestGetMetricsTimeout(t *testing.T) {
+	hasDeadline := mock.MatchedBy(func(ctx context.Context) bool {
+		deadline, ok := ctx.Deadline()
+		return ok && time.Until(deadline) <= 5*time.Second
+	})
+
+	ma := new(MockMetricsAggregator)
+	ma.On("GetConceptMetrics", hasDeadline, testConceptsUUIDs).Return([]concept.Concept{}, fmt.Errorf("error in getting annotations count: %w", concept.WithKind(concept.ErrTimeout, errors.New("failed executing queries: neo4j transaction timed out"))))
+
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	h := NewConceptsMetricsHandler(ma, 10, 5*time.Second, log)
+	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
+	w := httptest.NewRecorder()
+
+	h.GetMetrics(w, req)
+	resp := w.Result()
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+	actualJSONBody, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"message":"error in getting annotations count: failed executing queries: neo4j transaction timed out","code":"timeout"}`, string(actualJSONBody))
+
+	ma.AssertExpectations(t)
+}
+
+func TestGetMetricsTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	inGetMetricsSpan := mock.MatchedBy(func(ctx context.Context) bool {
+		return trace.SpanContextFromContext(ctx).TraceID().String() == "4bf92f3577b34da6a3ce929d0e0e4736"
+	})
+
+	ma := new(MockMetricsAggregator)
+	ma.On("GetConceptMetrics", inGetMetricsSpan, testConceptsUUIDs).Return(testConcepts, nil)
+
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
+	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+
+	tracing.Handler(http.HandlerFunc(h.GetMetrics)).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "GetMetrics", spans[0].Name())
+		assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+		assert.True(t, spans[0].Parent().IsRemote())
+	}
+	ma.AssertExpectations(t)
+}
+
+func TestGetMetricsProfile(t *testing.T) {
+	isProfiling := mock.MatchedBy(func(ctx context.Context) bool {
+		return concept.Profiles(ctx) != nil
+	})
+
+	ma := new(MockMetricsAggregator)
+	ma.On("GetConceptMetrics", isProfiling, testConceptsUUIDs).Return(testConcepts, nil)
+
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
+	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam+"&profile=true", nil)
+	w := httptest.NewRecorder()
+
+	h.GetMetrics(w, req)
+	resp := w.Result()
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	actualJSONBody, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"concepts":`+testJSONPayload+`,"profiles":[]}`, string(actualJSONBody))
+
+	ma.AssertExpectations(t)
+}
+
+func TestGetMetricsInvalidProfile(t *testing.T) {
+	tests := map[string]struct {
+		query           string
+		expectedMessage string
+	}{
+		"not a boolean": {
+			query:           "&profile=yes",
+			expectedMessage: "profile URL query parameter must be a boolean",
+		},
+		"csv": {
+			query:           "&profile=true&format=csv",
+			expectedMessage: "profile URL query parameter is not supported with the CSV format",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ma := new(MockMetricsAggregator)
+			log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+			h := NewConceptsMetricsHandler(ma, 10, 0, log)
+			req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam+test.query, nil)
+			w := httptest.NewRecorder()
+
+			h.GetMetrics(w, req)
+			resp := w.Result()
+
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			actualJSONBody, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.JSONEq(t, `{"message":"`+test.expectedMessage+`","code":"invalid_request"}`, string(actualJSONBody))
+
+			ma.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetMetricsErrorKinds(t *testing.T) {
+	tests := map[string]struct {
+		kind           error
+		expectedStatus int
+		expectedCode   string
+	}{
+		"validation": {
+			kind:           concept.ErrValidation,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+		},
+		"database unavailable": {
+			kind:           concept.ErrDatabaseUnavailable,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   "database_unavailable",
+		},
+		"timeout": {
+			kind:           concept.ErrTimeout,
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedCode:   "timeout",
+		},
+		"overload": {
+			kind:           concept.ErrOverload,
+			expectedStatus: http.StatusTooManyRequests,
+			expectedCode:   "overloaded",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ma := new(MockMetricsAggregator)
+			ma.On("GetConceptMetrics", mock.Anything, testConceptsUUIDs).Return([]concept.Concept{}, concept.WithKind(test.kind, errors.New("computer says no")))
+
+			log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+			h := NewConceptsMetricsHandler(ma, 10, 0, log)
+			req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
+			w := httptest.NewRecorder()
+
+			h.GetMetrics(w, req)
+			resp := w.Result()
+
+			defer resp.Body.Close()
+
+			assert.Equal(t, test.expectedStatus, resp.StatusCode)
+			actualJSONBody, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.JSONEq(t, `{"message":"computer says no","code":"`+test.expectedCode+`"}`, string(actualJSONBody))
+
+			ma.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetMetricsCircuitOpen(t *testing.T) {
+	ma := new(MockMetricsAggregator)
+	ma.On("GetConceptMetrics", mock.Anything, testConceptsUUIDs).Return([]concept.Concept{}, fmt.Errorf("error in getting annotations count: %w", &concept.CircuitOpenError{RetryAfter: 12500 * time.Millisecond}))
+
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
+	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
+	w := httptest.NewRecorder()
+
+	h.GetMetrics(w, req)
+	resp := w.Result()
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "13", resp.Header.Get("Retry-After"))
+	actualJSONBody, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"message":"error in getting annotations count: circuit breaker is open, Neo4j is not queried until it recovers","code":"database_unavailable"}`, string(actualJSONBody))
+
+	ma.AssertExpectations(t)
+}
+
+func TestHappyGetSummary(t *testing.T) {
+	summary := concept.Summary{
+		CanonicalConceptsCount:   3,
+		ConceptsByType:           map[string]int64{"Person": 2, "Topic": 1},
+		ContentCount:             136,
+		UnannotatedConceptsCount: 1,
+		AnnotationsPerConcept:    concept.Distribution{Min: 0, P50: 12, P75: 123, P90: 123, P95: 123, P99: 123, Max: 123},
+	}
+
+	ma := new(MockMetricsAggregator)
+	ma.On("GetSummary", mock.AnythingOfType("*context.cancelCtx")).Return(summary, nil)
+
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
+	req := httptest.NewRequest("GET", "http://localhost:8080/metrics/summary", nil)
+	w := httptest.NewRecorder()
+
+	h.GetSummary(w, req)
+	resp := w.Result()
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	actualJSONBody, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `
+{
+  "canonicalConceptsCount": 3,
+  "conceptsByType": {"Person": 2, "Topic": 1},
+  "contentCount": 136,
+  "unannotatedConceptsCount": 1,
+  "annotationsPerConcept": {"min": 0, "p50": 12, "p75": 123, "p90": 123, "p95": 123, "p99": 123, "max": 123}
+}`, string(actualJSONBody))
+
+	ma.AssertExpectations(t)
+}
+
+func TestGetSummaryError(t *testing.T) {
+	ma := new(MockMetricsAggregator)
+	ma.On("GetSummary", mock.AnythingOfType("*context.cancelCtx")).Return(concept.Summary{}, errors.New("computer says no"))
+
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
+	req := httptest.NewRequest("GET", "http://localhost:8080/metrics/summary", nil)
+	w := httptest.NewRecorder()
+
+	h.GetSummary(w, req)
+	resp := w.Result()
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	actualJSONBody, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"message":"computer says no","code":"internal_error"}`, string(actualJSONBody))
+
+	ma.AssertExpectations(t)
+}
+
+var testOrphansReport = concept.OrphansReport{
+	Kind:   concept.OrphanKindUnannotated,
+	Offset: 0,
+	Limit:  2,
+	Concepts: []concept.OrphanConcept{
+		{UUID: testConceptsUUIDs[0], PrefLabel: "Jane Doe", Types: []string{"Person"}},
+		{UUID: testConceptsUUIDs[1], PrefLabel: "Acme, Inc.", Types: []string{"Organisation", "Company"}},
+	},
+}
+
+func TestHappyGetOrphans(t *testing.T) {
+	ma := new(MockMetricsAggregator)
+	ma.On("GetOrphans", mock.AnythingOfType("*context.cancelCtx"), concept.OrphanKindUnannotated, int64(1633046400), 0, 2).Return(testOrphansReport, nil)
+
+	log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+	h := NewConceptsMetricsHandler(ma, 10, 0, log)
+	req := httptest.NewRequest("GET", "http://localhost:8080/concepts/orphans?kind=unannotated&since=2021-10-01T00:00:00Z&limit=2", nil)
+	w := httptest.NewRecorder()
+
+	h.GetOrphans(w, req)
+	resp := w.Result()
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	actualJSONBody, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `
+{
+  "kind": "unannotated",
+  "offset": 0,
+  "limit": 2,
+  "concepts": [
+    {"uuid": "38ea6443-050e-4d02-9564-537490f84abd", "prefLabel": "Jane Doe", "types": ["Person"]},
+    {"uuid": "a4de0e8f-96f4-4ccf-ba26-410f005e021b", "prefLabel": "Acme, Inc.", "types": ["Organisation", "Company"]}
+  ]
+}`, string(actualJSONBody))
+
+	ma.AssertExpectations(t)
+}
+
+func TestGetOrphansCSV(t *testing.T) {
+	tests := map[string]struct {
+		url    string
+		accept string
+	}{
+		"format query parameter": {
+			url: "http://localhost:8080/concepts/orphans?kind=unannotated&limit=2&format=csv",
+		},
+		"accept header": {
+			url:    "http://localhost:8080/concepts/orphans?kind=unannotated&limit=2",
+			accept: "text/csv",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ma := new(MockMetricsAggregator)
+			ma.On("GetOrphans", mock.AnythingOfType("*context.cancelCtx"), concept.OrphanKindUnannotated, int64(0), 0, 2).Return(testOrphansReport, nil)
+
+			log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+			h := NewConceptsMetricsHandler(ma, 10, 0, log)
+			req := httptest.NewRequest("GET", test.url, nil)
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+			w := httptest.NewRecorder()
+
+			h.GetOrphans(w, req)
+			resp := w.Result()
+
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+			actualBody, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, "uuid,prefLabel,types\n"+
+				"38ea6443-050e-4d02-9564-537490f84abd,Jane Doe,Person\n"+
+				"a4de0e8f-96f4-4ccf-ba26-410f005e021b,\"Acme, Inc.\",Organisation;Company\n", string(actualBody))
+
+			ma.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetOrphansInvalidParams(t *testing.T) {
+	tests := map[string]struct {
+		query           string
+		expectedMessage string
+	}{
+		"missing kind": {
+			query:           "",
+			expectedMessage: "kind URL query parameter must be one of [unannotated unsourced unattached]",
+		},
+		"unknown kind": {
+			query:           "?kind=lonely",
+			expectedMessage: "kind URL query parameter must be one of [unannotated unsourced unattached]",
+		},
+		"since for unsupported kind": {
+			query:           "?kind=unsourced&since=2021-10-01T00:00:00Z",
+			expectedMessage: "since URL query parameter is supported only for kind unannotated",
+		},
+		"malformed since": {
+			query:           "?kind=unannotated&since=yesterday",
+			expectedMessage: "since URL query parameter must be an RFC3339 timestamp",
+		},
+		"negative offset": {
+			query:           "?kind=unattached&offset=-1",
+			expectedMessage: "offset URL query parameter must be a non-negative integer",
+		},
+		"too big limit": {
+			query:           "?kind=unattached&limit=1001",
+			expectedMessage: "limit URL query parameter must be an integer between 1 and 1000",
+		},
+		"unknown format": {
+			query:           "?kind=unattached&format=xml",
+			expectedMessage: "format URL query parameter must be either json or csv",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ma := new(MockMetricsAggregator)
+			log := logger.NewUPPInfoLogger("test-neo4j-metric-aggregator")
+
+			h := NewConceptsMetricsHandler(ma, 10, 0, log)
+			req := httptest.NewRequest("GET", "http://localhost:8080/concepts/orphans"+test.query, nil)
+			w := httptest.NewRecorder()
+
+			h.GetOrphans(w, req)
+			resp := w.Result()
+
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			actualJSONBody, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.JSONEq(t, `{"message":"`+test.expectedMessage+`","code":"invalid_request"}`, string(actualJSONBody))
+
+			ma.AssertExpectations(t)
+		})
+	}
+}
+
 type MockMetricsAggregator struct {
 	mock.Mock
 }
@@ -181,3 +838,13 @@ func (m *MockMetricsAggregator) GetConceptMetrics(ctx context.Context, conceptUU
 	args := m.Called(ctx, conceptUUIDs)
 	return args.Get(0).([]concept.Concept), args.Error(1)
 }
+
+func (m *MockMetricsAggregator) GetSummary(ctx context.Context) (concept.Summary, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(concept.Summary), args.Error(1)
+}
+
+func (m *MockMetricsAggregator) GetOrphans(ctx context.Context, kind concept.OrphanKind, since int64, offset int, limit int) (concept.OrphansReport, error) {
+	args := m.Called(ctx, kind, since, offset, limit)
+	return args.Get(0).(concept.OrphansReport), args.Error(1)
+}
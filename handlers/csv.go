@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const csvContentType = "text/csv"
+
+// wantsCSV reports whether the client asked for a CSV response, either with the format URL query parameter or
+// with the Accept header. The Accept header is honoured in the order of its q-values, with a specific media type
+// preferred over a wildcard of the same weight, and JSON is returned unless CSV is preferred.
+func wantsCSV(r *http.Request) (bool, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		switch strings.ToLower(format) {
+		case "csv":
+			return true, nil
+		case "json":
+			return false, nil
+		}
+		return false, errors.New("format URL query parameter must be either json or csv")
+	}
+
+	csvWeight, jsonWeight := acceptedWeights(r.Header.Get("Accept"))
+	return csvWeight > 0 && csvWeight > jsonWeight, nil
+}
+
+// acceptedWeights returns the q-values of the CSV and the JSON media types in the Accept header, 0 when they are
+// not acceptable. The weights of the wildcards are lowered a little, so that they lose to the specific media types.
+func acceptedWeights(accept string) (csvWeight float64, jsonWeight float64) {
+	const wildcardPenalty = 0.0001
+
+	csvWeight, jsonWeight = -1, -1
+	var csvWildcard, jsonWildcard float64
+	for _, accepted := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		weight := 1.0
+		if q, ok := params["q"]; ok {
+			if weight, err = strconv.ParseFloat(q, 64); err != nil || weight < 0 || weight > 1 {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case csvContentType:
+			csvWeight = math.Max(csvWeight, weight)
+		case "application/json":
+			jsonWeight = math.Max(jsonWeight, weight)
+		case "text/*":
+			csvWildcard = math.Max(csvWildcard, weight-wildcardPenalty)
+		case "application/*":
+			jsonWildcard = math.Max(jsonWildcard, weight-wildcardPenalty)
+		case "*/*":
+			csvWildcard = math.Max(csvWildcard, weight-wildcardPenalty)
+			jsonWildcard = math.Max(jsonWildcard, weight-wildcardPenalty)
+		}
+	}
+
+	// an explicit q-value for a media type overrides the wildcards matching it, even when it is 0
+	if csvWeight < 0 {
+		csvWeight = csvWildcard
+	}
+	if jsonWeight < 0 {
+		jsonWeight = jsonWildcard
+	}
+	return csvWeight, jsonWeight
+}
+
+func writeCSV(w http.ResponseWriter, header []string, rows [][]string) error {
+	w.Header().Set("Content-Type", csvContentType+"; charset=utf-8")
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/neo4j-metric-aggregator/concept"
+)
+
+const internalErrorCode = "internal_error"
+
+// errorKinds maps the kinds of errors defined in the concept package to the HTTP status and the machine readable
+// code of the responses. Any other error is an internal error.
+var errorKinds = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{concept.ErrValidation, http.StatusBadRequest, "invalid_request"},
+	{concept.ErrDatabaseUnavailable, http.StatusServiceUnavailable, "database_unavailable"},
+	{concept.ErrTimeout, http.StatusGatewayTimeout, "timeout"},
+	{concept.ErrOverload, http.StatusTooManyRequests, "overloaded"},
+}
+
+// errorStatus returns the HTTP status and the code of the response to the given error.
+func errorStatus(err error) (int, string) {
+	for _, k := range errorKinds {
+		if errors.Is(err, k.kind) {
+			return k.status, k.code
+		}
+	}
+	return http.StatusInternalServerError, internalErrorCode
+}
+
+// setRetryAfterHeader tells the clients when to retry the requests rejected because the circuit breaker is open.
+func setRetryAfterHeader(w http.ResponseWriter, err error) {
+	var openErr *concept.CircuitOpenError
+	if !errors.As(err, &openErr) {
+		return
+	}
+	setRetryAfter(w, openErr.RetryAfter)
+}
+
+// setRetryAfter sets the Retry-After header to the given delay, rounded up to whole seconds.
+func setRetryAfter(w http.ResponseWriter, delay time.Duration) {
+	seconds := int(math.Ceil(delay.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
+
+// writeErrorResponse writes the JSON body of the error responses, with a human readable message and a machine
+// readable code.
+func writeErrorResponse(w http.ResponseWriter, status int, code string, message string, log *log.UPPLogger) {
+	w.WriteHeader(status)
+
+	body := make(map[string]interface{})
+	body["message"] = message
+	body["code"] = code
+	j, err := json.Marshal(&body)
+	if err != nil {
+		log.WithError(err).Error("Failed to parse provided message to json, this is a bug.")
+		return
+	}
+
+	if _, err := w.Write(j); err != nil {
+		log.WithError(err).Error("Failed to write json data to response")
+		return
+	}
+}
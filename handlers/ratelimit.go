@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Financial-Times/go-logger/v2"
+	tidUtils "github.com/Financial-Times/transactionid-utils-go"
+	"golang.org/x/time/rate"
+)
+
+// RateLimit is a token bucket refilled with RequestsPerSecond tokens every second, up to Burst tokens.
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	Burst             int     `json:"burst"`
+}
+
+// RateLimits are the rate limits of the clients, keyed by client ID. The clients without their own limit get a
+// bucket of the Default size each, or are not limited when it is nil.
+type RateLimits struct {
+	Default *RateLimit           `json:"default"`
+	Clients map[string]RateLimit `json:"clients"`
+}
+
+// LoadRateLimits reads the rate limits from the JSON file at the given path.
+func LoadRateLimits(path string) (RateLimits, error) {
+	var limits RateLimits
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return limits, fmt.Errorf("failed reading rate limits file: %w", err)
+	}
+	if err = json.Unmarshal(data, &limits); err != nil {
+		return limits, fmt.Errorf("failed parsing rate limits file: %w", err)
+	}
+	return limits, limits.Validate()
+}
+
+// Validate checks that every rate limit refills and holds at least one token.
+func (l RateLimits) Validate() error {
+	if l.Default != nil {
+		if err := l.Default.validate(); err != nil {
+			return fmt.Errorf("invalid default rate limit: %w", err)
+		}
+	}
+	for client, limit := range l.Clients {
+		if err := limit.validate(); err != nil {
+			return fmt.Errorf("invalid rate limit for client %v: %w", client, err)
+		}
+	}
+	return nil
+}
+
+func (l RateLimit) validate() error {
+	if l.RequestsPerSecond <= 0 || l.Burst < 1 {
+		return errors.New("requestsPerSecond and burst must be positive")
+	}
+	return nil
+}
+
+// maxDefaultLimiters caps the number of buckets of the clients without their own limit, which anyone can create
+// by sending a new X-Client-ID header.
+const maxDefaultLimiters = 10000
+
+// RateLimiter rejects the requests of the clients exceeding their rate limit.
+type RateLimiter struct {
+	log                *log.UPPLogger
+	maxDefaultLimiters int
+
+	mu              sync.Mutex
+	limits          RateLimits
+	limiters        map[string]*rate.Limiter
+	defaultLimiters map[string]*rate.Limiter
+	overflow        *rate.Limiter
+}
+
+func NewRateLimiter(limits RateLimits, log *log.UPPLogger) *RateLimiter {
+	l := &RateLimiter{log: log, maxDefaultLimiters: maxDefaultLimiters}
+	l.SetLimits(limits)
+	return l
+}
+
+// SetLimits replaces the rate limits of the clients. The buckets of all the clients start full again.
+func (l *RateLimiter) SetLimits(limits RateLimits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limits = limits
+	l.limiters = make(map[string]*rate.Limiter)
+	l.defaultLimiters = make(map[string]*rate.Limiter)
+	l.overflow = nil
+	if limits.Default != nil {
+		l.overflow = newLimiter(*limits.Default)
+	}
+}
+
+// limiterFor returns the token bucket of the given client, nil when it is not limited.
+func (l *RateLimiter) limiterFor(client string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if limit, ok := l.limits.Clients[client]; ok {
+		limiter, ok := l.limiters[client]
+		if !ok {
+			limiter = newLimiter(limit)
+			l.limiters[client] = limiter
+		}
+		return limiter
+	}
+
+	if l.limits.Default == nil {
+		return nil
+	}
+	if limiter, ok := l.defaultLimiters[client]; ok {
+		return limiter
+	}
+	if len(l.defaultLimiters) >= l.maxDefaultLimiters {
+		l.evictFullLimiters()
+	}
+	if len(l.defaultLimiters) >= l.maxDefaultLimiters {
+		// every client is still throttled, the new ones share a bucket until some of them go quiet
+		return l.overflow
+	}
+	limiter := newLimiter(*l.limits.Default)
+	l.defaultLimiters[client] = limiter
+	return limiter
+}
+
+// evictFullLimiters drops the buckets of the clients without their own limit which filled up again, since they
+// behave as new ones.
+func (l *RateLimiter) evictFullLimiters() {
+	now := time.Now()
+	for client, limiter := range l.defaultLimiters {
+		if limiter.TokensAt(now) >= float64(limiter.Burst()) {
+			delete(l.defaultLimiters, client)
+		}
+	}
+}
+
+func newLimiter(limit RateLimit) *rate.Limiter {
+	return rate.NewLimiter(rate.Limit(limit.RequestsPerSecond), limit.Burst)
+}
+
+// Handler wraps next, responding with 429 Too Many Requests to the requests of the clients which exceeded their
+// rate limit. The X-RateLimit-Limit and X-RateLimit-Remaining headers tell the clients the size of their bucket
+// and the requests left in it, and Retry-After when they can make the next request once rejected.
+func (l *RateLimiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := clientID(r)
+		limiter := l.limiterFor(rateLimitKey(r))
+		if limiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		now := time.Now()
+		reservation := limiter.ReserveN(now, 1)
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limiter.Burst()))
+
+		if delay := reservation.DelayFrom(now); delay > 0 {
+			reservation.CancelAt(now)
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			setRetryAfter(w, delay)
+
+			l.log.WithField(tidUtils.TransactionIDKey, tidUtils.GetTransactionIDFromRequest(r)).
+				WithField("clientID", client).
+				Warn("rejecting request, client rate limit exceeded")
+			w.Header().Set("Content-Type", "application/json")
+			writeErrorResponse(w, http.StatusTooManyRequests, "rate_limited", "rate limit exceeded", l.log)
+			return
+		}
+
+		remaining := int(math.Max(0, math.Floor(limiter.TokensAt(now))))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logger "github.com/Financial-Times/go-logger/v2"
+)
+
+func TestLoadRateLimits(t *testing.T) {
+	tests := map[string]struct {
+		content        string
+		expectedLimits RateLimits
+		expectedError  string
+	}{
+		"valid": {
+			content: `{"default": {"requestsPerSecond": 1, "burst": 5}, "clients": {"search-indexer": {"requestsPerSecond": 50.5, "burst": 100}}}`,
+			expectedLimits: RateLimits{
+				Default: &RateLimit{RequestsPerSecond: 1, Burst: 5},
+				Clients: map[string]RateLimit{"search-indexer": {RequestsPerSecond: 50.5, Burst: 100}},
+			},
+		},
+		"without default": {
+			content: `{"clients": {"search-indexer": {"requestsPerSecond": 50, "burst": 100}}}`,
+			expectedLimits: RateLimits{
+				Clients: map[string]RateLimit{"search-indexer": {RequestsPerSecond: 50, Burst: 100}},
+			},
+		},
+		"invalid json": {
+			content:       `{"clients": `,
+			expectedError: "failed parsing rate limits file",
+		},
+		"invalid client limit": {
+			content:       `{"clients": {"search-indexer": {"requestsPerSecond": 50}}}`,
+			expectedError: "invalid rate limit for client search-indexer",
+		},
+		"invalid default limit": {
+			content:       `{"default": {"requestsPerSecond": 0, "burst": 5}}`,
+			expectedError: "invalid default rate limit",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rate-limits.json")
+			require.NoError(t, os.WriteFile(path, []byte(test.content), 0600))
+
+			limits, err := LoadRateLimits(path)
+			if test.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedLimits, limits)
+		})
+	}
+}
+
+func TestLoadRateLimitsMissingFile(t *testing.T) {
+	_, err := LoadRateLimits(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestRateLimiter(t *testing.T) {
+	limits := RateLimits{
+		Default: &RateLimit{RequestsPerSecond: 0.001, Burst: 1},
+		Clients: map[string]RateLimit{"search-indexer": {RequestsPerSecond: 0.001, Burst: 2}},
+	}
+	l := NewRateLimiter(limits, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	handler := l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	call := func(client string) *http.Response {
+		req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
+		if client != "" {
+			req.Header.Set(ClientIDHeader, client)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	resp := call("search-indexer")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", resp.Header.Get("X-RateLimit-Remaining"))
+
+	resp = call("search-indexer")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get("X-RateLimit-Remaining"))
+
+	resp = call("search-indexer")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", resp.Header.Get("X-RateLimit-Remaining"))
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"message":"rate limit exceeded","code":"rate_limited"}`, string(body))
+
+	// the clients without their own limit get a bucket of the default size each
+	resp = call("ad-hoc-script")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("X-RateLimit-Limit"))
+	assert.Equal(t, http.StatusTooManyRequests, call("ad-hoc-script").StatusCode)
+	assert.Equal(t, http.StatusOK, call("").StatusCode)
+	assert.Equal(t, http.StatusTooManyRequests, call("").StatusCode)
+}
+
+func TestRateLimiterCapsDefaultBuckets(t *testing.T) {
+	l := NewRateLimiter(RateLimits{Default: &RateLimit{RequestsPerSecond: 0.001, Burst: 1}}, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	l.maxDefaultLimiters = 2
+
+	assert.True(t, l.limiterFor("client-1").Allow())
+	assert.True(t, l.limiterFor("client-2").Allow())
+
+	// the throttled clients keep their bucket, the new ones share the overflow bucket
+	assert.False(t, l.limiterFor("client-1").Allow())
+	assert.True(t, l.limiterFor("client-3").Allow())
+	assert.False(t, l.limiterFor("client-4").Allow())
+	assert.Len(t, l.defaultLimiters, 2)
+}
+
+func TestRateLimiterEvictsFullBuckets(t *testing.T) {
+	l := NewRateLimiter(RateLimits{Default: &RateLimit{RequestsPerSecond: 1000, Burst: 1}}, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	l.maxDefaultLimiters = 2
+
+	assert.True(t, l.limiterFor("client-1").Allow())
+	assert.True(t, l.limiterFor("client-2").Allow())
+	time.Sleep(10 * time.Millisecond)
+
+	limiter := l.limiterFor("client-3")
+	assert.NotSame(t, l.overflow, limiter)
+	assert.True(t, limiter.Allow())
+	assert.Len(t, l.defaultLimiters, 1)
+}
+
+func TestRateLimiterKeysAuthenticatedRequestsOnAPIKey(t *testing.T) {
+	a, err := NewAuthenticator("search-indexer:s3cr3t, 4n0th3r-s3cr3t", "", logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	require.NoError(t, err)
+	l := NewRateLimiter(RateLimits{Default: &RateLimit{RequestsPerSecond: 0.001, Burst: 1}}, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	handler := a.Handler(l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	call := func(key string, client string) int {
+		req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
+		req.Header.Set("X-Api-Key", key)
+		req.Header.Set(ClientIDHeader, client)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Result().StatusCode
+	}
+
+	// changing the X-Client-ID header does not give an authenticated client a new bucket
+	assert.Equal(t, http.StatusOK, call("4n0th3r-s3cr3t", "ad-hoc-script"))
+	assert.Equal(t, http.StatusTooManyRequests, call("4n0th3r-s3cr3t", "another-script"))
+	assert.Equal(t, http.StatusOK, call("s3cr3t", "another-script"))
+	assert.Equal(t, http.StatusTooManyRequests, call("s3cr3t", "ad-hoc-script"))
+}
+
+func TestRateLimiterWithoutDefault(t *testing.T) {
+	limits := RateLimits{Clients: map[string]RateLimit{"search-indexer": {RequestsPerSecond: 0.001, Burst: 1}}}
+	l := NewRateLimiter(limits, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	handler := l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.Empty(t, w.Result().Header.Get("X-RateLimit-Limit"))
+	}
+}
+
+func TestRateLimiterSetLimits(t *testing.T) {
+	l := NewRateLimiter(RateLimits{}, logger.NewUPPInfoLogger("test-neo4j-metric-aggregator"))
+	handler := l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	call := func() *http.Response {
+		req := httptest.NewRequest("GET", "http://localhost:8080/concepts/metrics"+testQueryParam, nil)
+		req.Header.Set(ClientIDHeader, "search-indexer")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	assert.Equal(t, http.StatusOK, call().StatusCode)
+	assert.Equal(t, http.StatusOK, call().StatusCode)
+
+	l.SetLimits(RateLimits{Clients: map[string]RateLimit{"search-indexer": {RequestsPerSecond: 0.001, Burst: 1}}})
+	assert.Equal(t, http.StatusOK, call().StatusCode)
+	assert.Equal(t, http.StatusTooManyRequests, call().StatusCode)
+
+	l.SetLimits(RateLimits{})
+	resp := call()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("X-RateLimit-Limit"))
+}
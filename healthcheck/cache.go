@@ -0,0 +1,76 @@
+package healthcheck
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
+)
+
+type checkResult struct {
+	output    string
+	err       error
+	checkedAt time.Time
+}
+
+// checkCache runs the checks and keeps their latest results, so that the health endpoints are served without
+// querying Neo4j on every call. The results older than maxAge are reported as failed.
+type checkCache struct {
+	checks []fthealth.Check
+	maxAge time.Duration
+	now    func() time.Time
+
+	mu      sync.RWMutex
+	results map[string]checkResult
+}
+
+func newCheckCache(checks []fthealth.Check, maxAge time.Duration) *checkCache {
+	return &checkCache{
+		checks:  checks,
+		maxAge:  maxAge,
+		now:     time.Now,
+		results: make(map[string]checkResult),
+	}
+}
+
+// run runs all the checks concurrently and caches their results.
+func (c *checkCache) run() {
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check fthealth.Check) {
+			defer wg.Done()
+			output, err := check.Checker()
+
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.results[check.ID] = checkResult{output: output, err: err, checkedAt: c.now()}
+		}(check)
+	}
+	wg.Wait()
+}
+
+// cached returns a copy of check returning the cached result of check instead of running it.
+func (c *checkCache) cached(check fthealth.Check) fthealth.Check {
+	check.Checker = func() (string, error) {
+		return c.result(check.ID)
+	}
+	return check
+}
+
+func (c *checkCache) result(id string) (string, error) {
+	c.mu.RLock()
+	res, ok := c.results[id]
+	c.mu.RUnlock()
+
+	if !ok {
+		err := fmt.Errorf("check has not run yet")
+		return err.Error(), err
+	}
+	if age := c.now().Sub(res.checkedAt); age > c.maxAge {
+		err := fmt.Errorf("last check result is stale, checked %v ago at %v", age.Round(time.Second), res.checkedAt.Format(time.RFC3339))
+		return err.Error(), err
+	}
+	return res.output, res.err
+}
@@ -0,0 +1,49 @@
+package healthcheck
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
+)
+
+func TestCheckCache(t *testing.T) {
+	calls := 0
+	healthy := fthealth.Check{ID: "healthy", Checker: func() (string, error) {
+		calls++
+		return "all good", nil
+	}}
+	unhealthy := fthealth.Check{ID: "unhealthy", Checker: func() (string, error) {
+		return "all bad", errors.New("computer says no")
+	}}
+
+	now := time.Date(2021, 10, 4, 16, 2, 11, 0, time.UTC)
+	cache := newCheckCache([]fthealth.Check{healthy, unhealthy}, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cachedHealthy := cache.cached(healthy)
+	cachedUnhealthy := cache.cached(unhealthy)
+
+	output, err := cachedHealthy.Checker()
+	assert.EqualError(t, err, "check has not run yet")
+	assert.Equal(t, "check has not run yet", output)
+
+	cache.run()
+	assert.Equal(t, 1, calls)
+
+	output, err = cachedHealthy.Checker()
+	assert.NoError(t, err)
+	assert.Equal(t, "all good", output)
+	output, err = cachedUnhealthy.Checker()
+	assert.EqualError(t, err, "computer says no")
+	assert.Equal(t, "all bad", output)
+	assert.Equal(t, 1, calls, "the cached checks must not run the checker")
+
+	now = now.Add(2 * time.Minute)
+	output, err = cachedHealthy.Checker()
+	assert.Error(t, err)
+	assert.Equal(t, "last check result is stale, checked 2m0s ago at 2021-10-04T16:02:11Z", output)
+}
@@ -0,0 +1,50 @@
+package healthcheck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Financial-Times/neo4j-metric-aggregator/concept"
+)
+
+type countFunc func(ctx context.Context, conceptUUIDs []string) (map[string]concept.Metrics, error)
+
+func (f countFunc) Count(ctx context.Context, conceptUUIDs []string) (map[string]concept.Metrics, error) {
+	return f(ctx, conceptUUIDs)
+}
+
+func TestCanaryQueryChecker(t *testing.T) {
+	tests := map[string]struct {
+		canaryConceptUUID string
+		expectedOutput    string
+		expectedCounts    []string
+	}{
+		"canary concept": {
+			canaryConceptUUID: "601a5957-74ab-4eab-8a43-4596355c9420",
+			expectedOutput:    "Computed the metrics of concept 601a5957-74ab-4eab-8a43-4596355c9420",
+			expectedCounts:    []string{"601a5957-74ab-4eab-8a43-4596355c9420"},
+		},
+		"no canary concept": {
+			expectedOutput: "No canary concept configured, the query latency is not checked",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var counted []string
+			counter := countFunc(func(ctx context.Context, conceptUUIDs []string) (map[string]concept.Metrics, error) {
+				counted = append(counted, conceptUUIDs...)
+				return map[string]concept.Metrics{}, nil
+			})
+			config := Config{CanaryConceptUUID: test.canaryConceptUUID, CanaryLatencyThreshold: time.Minute, CheckInterval: time.Minute, MaxResultAge: time.Hour}
+
+			output, err := NewHealthService("", "", "", nil, counter, nil, config).canaryQueryChecker()
+			assert.NoError(t, err)
+			assert.Contains(t, output, test.expectedOutput)
+			assert.Equal(t, test.expectedCounts, counted)
+		})
+	}
+}
@@ -1,40 +1,137 @@
 package healthcheck
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"sync/atomic"
 	"time"
 
-	cmneo4j "github.com/Financial-Times/cm-neo4j-driver"
 	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
+	"github.com/Financial-Times/neo4j-metric-aggregator/concept"
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
 	"github.com/Financial-Times/service-status-go/gtg"
 )
 
+const newestContentQuery = `
+	MATCH (content:Content)
+	WHERE content.publishedDateEpoch IS NOT NULL
+	RETURN content.publishedDateEpoch AS publishedDateEpoch
+	ORDER BY content.publishedDateEpoch DESC
+	LIMIT 1
+`
+
+// The IDs of the checks.
+const (
+	neo4jCheckID          = "check-neo4j-healthCheck"
+	circuitBreakerCheckID = "check-neo4j-circuit-breaker"
+	canaryQueryCheckID    = "check-neo4j-query-latency"
+	indexesCheckID        = "check-neo4j-indexes"
+	freshnessCheckID      = "check-content-freshness"
+)
+
+// Config holds the settings of the checks querying Neo4j.
+type Config struct {
+	// CanaryConceptUUID is the concept whose metrics are computed to check the latency of the queries. The latency
+	// is not checked when it is empty.
+	CanaryConceptUUID string
+	// CanaryLatencyThreshold is the time above which computing the metrics of the canary concept is too slow.
+	CanaryLatencyThreshold time.Duration
+	// MaxContentAge is the age of the newest content above which the knowledge base is considered stale.
+	MaxContentAge time.Duration
+	// CheckInterval is how often the checks are run by Start.
+	CheckInterval time.Duration
+	// MaxResultAge is the age of the latest result of a check above which the check is reported as failed.
+	MaxResultAge time.Duration
+}
+
 type HealthService struct {
 	fthealth.TimedHealthCheck
-	neo4jDriver *cmneo4j.Driver
+	neo4jDriver        *neo.Driver
+	annotationsCounter concept.AnnotationsCounter
+	circuitBreaker     *concept.CircuitBreaker
+	config             Config
+	cache              *checkCache
+	gtgChecks          []fthealth.Check
+	readyChecks        []fthealth.Check
+	shuttingDown       atomic.Bool
 }
 
-func NewHealthService(appSystemCode string, appName string, appDescription string, neo4jDriver *cmneo4j.Driver) *HealthService {
+// NewHealthService creates the health checks of the service. The canary check computes the metrics with
+// annotationsCounter, which must query Neo4j directly rather than through the circuit breaker. The checks are run
+// in the background by Start, and the health endpoints report their latest results.
+func NewHealthService(appSystemCode string, appName string, appDescription string, neo4jDriver *neo.Driver, annotationsCounter concept.AnnotationsCounter, circuitBreaker *concept.CircuitBreaker, config Config) *HealthService {
 	hcService := &HealthService{}
 	hcService.neo4jDriver = neo4jDriver
+	hcService.annotationsCounter = annotationsCounter
+	hcService.circuitBreaker = circuitBreaker
+	hcService.config = config
 	hcService.SystemCode = appSystemCode
 	hcService.Name = appName
 	hcService.Description = appDescription
 	hcService.Timeout = 10 * time.Second
-	hcService.Checks = []fthealth.Check{
+	checks := []fthealth.Check{
 		hcService.neo4jCheck(),
+		hcService.circuitBreakerCheck(),
+		hcService.canaryQueryCheck(),
+		hcService.indexesCheck(),
+		hcService.contentFreshnessCheck(),
 	}
+	hcService.cache = newCheckCache(checks, config.MaxResultAge)
+	for _, check := range checks {
+		hcService.Checks = append(hcService.Checks, hcService.cache.cached(check))
+	}
+	// stale data is only a warning, the service is still good to go. The circuit breaker only closes on a metrics
+	// request, which a service taken out of rotation for not being good to go would never get. The slow queries and
+	// the missing indexes affect all the instances at once, taking them all out of rotation would be an outage.
+	hcService.gtgChecks = checksByID(hcService.Checks, neo4jCheckID)
+	// the readiness only depends on the checks which recover on their own once Neo4j does
+	hcService.readyChecks = checksByID(hcService.Checks, neo4jCheckID)
 	return hcService
 }
 
+// checksByID returns the checks with the given IDs, in the order of checks.
+func checksByID(checks []fthealth.Check, ids ...string) []fthealth.Check {
+	var selected []fthealth.Check
+	for _, check := range checks {
+		if slices.Contains(ids, check.ID) {
+			selected = append(selected, check)
+		}
+	}
+	return selected
+}
+
+// RunChecks runs all the checks concurrently, updating the results reported by the health endpoints.
+func (service *HealthService) RunChecks() {
+	service.cache.run()
+}
+
+// Start runs the checks right away and then every CheckInterval, until ctx is done.
+func (service *HealthService) Start(ctx context.Context) {
+	service.RunChecks()
+
+	ticker := time.NewTicker(service.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			service.RunChecks()
+		}
+	}
+}
+
 func (service *HealthService) HealthCheckHandleFunc() func(w http.ResponseWriter, r *http.Request) {
 	return fthealth.Handler(service)
 }
 
 func (service *HealthService) neo4jCheck() fthealth.Check {
 	return fthealth.Check{
-		ID:               "check-neo4j-healthCheck",
+		ID:               neo4jCheckID,
 		BusinessImpact:   "No immediate business impact. Concept search may provide reduced quality results.",
 		Name:             "Check Neo4J Health",
 		PanicGuide:       "https://runbooks.in.ft.com/neo4j-metric-aggregator",
@@ -53,11 +150,138 @@ func (service *HealthService) neo4jChecker() (string, error) {
 	return "Neo4J is healthy", nil
 }
 
+func (service *HealthService) circuitBreakerCheck() fthealth.Check {
+	return fthealth.Check{
+		ID:               circuitBreakerCheckID,
+		BusinessImpact:   "No immediate business impact. Concept search may provide reduced quality results.",
+		Name:             "Check Neo4j circuit breaker",
+		PanicGuide:       "https://runbooks.in.ft.com/neo4j-metric-aggregator",
+		Severity:         2,
+		TechnicalSummary: "Concept metrics requests are rejected without querying Neo4j after repeated Neo4j failures",
+		Checker:          service.circuitBreakerChecker,
+	}
+}
+
+func (service *HealthService) circuitBreakerChecker() (string, error) {
+	state := service.circuitBreaker.State()
+	if state != concept.CircuitClosed {
+		err := fmt.Errorf("circuit breaker is %v", state)
+		return fmt.Sprintf("Neo4j circuit breaker is %v", state), err
+	}
+
+	return "Neo4j circuit breaker is closed", nil
+}
+
+func (service *HealthService) canaryQueryCheck() fthealth.Check {
+	return fthealth.Check{
+		ID:               canaryQueryCheckID,
+		BusinessImpact:   "No immediate business impact. Concept search may provide reduced quality results.",
+		Name:             "Check Neo4j query latency",
+		PanicGuide:       "https://runbooks.in.ft.com/neo4j-metric-aggregator",
+		Severity:         2,
+		TechnicalSummary: "Computing the metrics of a concept takes too long, the concept metrics requests may time out",
+		Checker:          service.canaryQueryChecker,
+	}
+}
+
+func (service *HealthService) canaryQueryChecker() (string, error) {
+	if service.config.CanaryConceptUUID == "" {
+		return "No canary concept configured, the query latency is not checked", nil
+	}
+
+	ctx, cancel := context.WithTimeout(concept.WithHealthCheck(context.Background()), service.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := service.annotationsCounter.Count(ctx, []string{service.config.CanaryConceptUUID})
+	elapsed := time.Since(start)
+	if err != nil {
+		return fmt.Sprintf("Failed computing the metrics of concept %v: %v", service.config.CanaryConceptUUID, err), err
+	}
+	if elapsed > service.config.CanaryLatencyThreshold {
+		err = fmt.Errorf("computing the metrics of a concept took %v, over the %v threshold", elapsed, service.config.CanaryLatencyThreshold)
+		return err.Error(), err
+	}
+
+	return fmt.Sprintf("Computed the metrics of concept %v in %v", service.config.CanaryConceptUUID, elapsed), nil
+}
+
+func (service *HealthService) indexesCheck() fthealth.Check {
+	return fthealth.Check{
+		ID:               indexesCheckID,
+		BusinessImpact:   "No immediate business impact. Concept search may provide reduced quality results.",
+		Name:             "Check Neo4j indexes",
+		PanicGuide:       "https://runbooks.in.ft.com/neo4j-metric-aggregator",
+		Severity:         2,
+		TechnicalSummary: "The indexes the metrics queries rely on are missing, the queries scan the whole knowledge base",
+		Checker:          service.indexesChecker,
+	}
+}
+
+func (service *HealthService) indexesChecker() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.Timeout)
+	defer cancel()
+
+	missing, err := service.neo4jDriver.MissingIndexes(ctx, concept.RequiredIndexes)
+	if err != nil {
+		return fmt.Sprintf("Failed looking up the Neo4j indexes: %v", err), err
+	}
+	if len(missing) > 0 {
+		err = fmt.Errorf("Neo4j indexes %v are missing or not online yet", missing)
+		return err.Error(), err
+	}
+
+	return "All the required Neo4j indexes are online", nil
+}
+
+func (service *HealthService) contentFreshnessCheck() fthealth.Check {
+	return fthealth.Check{
+		ID:               freshnessCheckID,
+		BusinessImpact:   "No immediate business impact. Concept search may rank concepts on outdated metrics.",
+		Name:             "Check content freshness",
+		PanicGuide:       "https://runbooks.in.ft.com/neo4j-metric-aggregator",
+		Severity:         3,
+		TechnicalSummary: "No content has been published recently, the knowledge base may not be updated anymore",
+		Checker:          service.contentFreshnessChecker,
+	}
+}
+
+func (service *HealthService) contentFreshnessChecker() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.Timeout)
+	defer cancel()
+
+	var res struct {
+		PublishedDateEpoch int64 `json:"publishedDateEpoch"`
+	}
+	err := service.neo4jDriver.Read(ctx, &neo.Query{Cypher: newestContentQuery, Result: &res})
+	if errors.Is(err, neo.ErrNoResultsFound) {
+		err = errors.New("no published content found")
+		return err.Error(), err
+	}
+	if err != nil {
+		return fmt.Sprintf("Failed looking up the newest content: %v", err), err
+	}
+
+	newest := time.Unix(res.PublishedDateEpoch, 0).UTC()
+	if age := time.Since(newest); age > service.config.MaxContentAge {
+		err = fmt.Errorf("the newest content was published %v ago, at %v", age.Round(time.Second), newest.Format(time.RFC3339))
+		return err.Error(), err
+	}
+
+	return fmt.Sprintf("The newest content was published at %v", newest.Format(time.RFC3339)), nil
+}
+
+// GTG reports whether Neo4j can be reached, from the latest result of the connectivity check.
 func (service *HealthService) GTG() gtg.Status {
+	return checksStatus(service.gtgChecks)
+}
+
+// checksStatus reports whether all the given checks passed, from their latest results.
+func checksStatus(fthealthChecks []fthealth.Check) gtg.Status {
 	var checks []gtg.StatusChecker
 
-	for idx := range service.Checks {
-		check := service.Checks[idx]
+	for idx := range fthealthChecks {
+		check := fthealthChecks[idx]
 
 		checks = append(checks, func() gtg.Status {
 			if _, err := check.Checker(); err != nil {
@@ -68,3 +292,23 @@ func (service *HealthService) GTG() gtg.Status {
 	}
 	return gtg.FailFastParallelCheck(checks)()
 }
+
+// Live reports whether the service is alive. It is answered as long as the HTTP server is serving, regardless of
+// Neo4j, so that an outage of Neo4j does not get the service restarted.
+func (service *HealthService) Live() gtg.Status {
+	return gtg.Status{GoodToGo: true}
+}
+
+// Ready reports whether the service can take requests: it is not shutting down and Neo4j can be reached.
+func (service *HealthService) Ready() gtg.Status {
+	if service.shuttingDown.Load() {
+		return gtg.Status{GoodToGo: false, Message: "shutting down"}
+	}
+	return checksStatus(service.readyChecks)
+}
+
+// ShutDown makes the service not ready anymore, so that no new requests are routed to it while the requests in
+// flight are drained.
+func (service *HealthService) ShutDown() {
+	service.shuttingDown.Store(true)
+}
@@ -3,28 +3,35 @@
 package healthcheck
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	cmneo4j "github.com/Financial-Times/cm-neo4j-driver"
 	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
 	logger "github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/neo4j-metric-aggregator/concept"
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
 	status "github.com/Financial-Times/service-status-go/httphandlers"
 )
 
 func TestHappyHealthCheck(t *testing.T) {
 	log := logger.NewUPPLogger("test-neo4j-metric-aggregator", "warning")
 	neoTestURL := getNeoTestURL(t)
-	d, err := cmneo4j.NewDefaultDriver(neoTestURL, log)
+	d, err := neo.NewDriver(neoTestURL, log, neo.DefaultConfig())
 	require.NoError(t, err)
 
-	h := NewHealthService("", "", "", d)
+	writeTestSchemaAndContent(t, d, time.Now())
+
+	h := newTestHealthService(d, concept.NewCircuitBreaker(5, time.Minute, log), log)
+	h.RunChecks()
 
 	req := httptest.NewRequest("GET", "/__health", nil)
 	w := httptest.NewRecorder()
@@ -38,8 +45,11 @@ func TestHappyHealthCheck(t *testing.T) {
 	err = json.NewDecoder(resp.Body).Decode(&result)
 
 	assert.NoError(t, err)
-	assert.Len(t, result.Checks, 1)
+	assert.Len(t, result.Checks, 5)
 	assert.True(t, result.Ok)
+	for _, check := range result.Checks {
+		assert.True(t, check.Ok, check.CheckOutput)
+	}
 
 	assert.True(t, result.Checks[0].Ok)
 	assert.Equal(t, "Neo4J is healthy", result.Checks[0].CheckOutput)
@@ -48,10 +58,11 @@ func TestHappyHealthCheck(t *testing.T) {
 
 func TestUnhappyHealthCheck(t *testing.T) {
 	log := logger.NewUPPLogger("test-neo4j-metric-aggregator", "warning")
-	d, err := cmneo4j.NewDefaultDriver("bolt://localhost:80", log)
+	d, err := neo.NewDriver("bolt://localhost:80", log, neo.DefaultConfig())
 	require.NoError(t, err)
 
-	h := NewHealthService("", "", "", d)
+	h := newTestHealthService(d, concept.NewCircuitBreaker(5, time.Minute, log), log)
+	h.RunChecks()
 
 	req := httptest.NewRequest("GET", "/__health", nil)
 	w := httptest.NewRecorder()
@@ -65,21 +76,59 @@ func TestUnhappyHealthCheck(t *testing.T) {
 	err = json.NewDecoder(resp.Body).Decode(&result)
 
 	assert.NoError(t, err)
-	assert.Len(t, result.Checks, 1)
+	assert.Len(t, result.Checks, 5)
 	assert.False(t, result.Ok)
+	for _, check := range result.Checks[2:] {
+		assert.False(t, check.Ok)
+	}
 
 	assert.False(t, result.Checks[0].Ok)
 	assert.NotEqual(t, "Neo4J is healthy", result.Checks[0].CheckOutput)
 	assert.Equal(t, "App cannot compute concept metrics from Neo4j", result.Checks[0].TechnicalSummary)
 }
 
+func TestOpenCircuitBreakerHealthCheck(t *testing.T) {
+	log := logger.NewUPPLogger("test-neo4j-metric-aggregator", "warning")
+	config := neo.DefaultConfig()
+	config.MaxTransactionRetryTime = 0
+	d, err := neo.NewDriver("bolt://localhost:80", log, config)
+	require.NoError(t, err)
+
+	breaker := concept.NewCircuitBreaker(1, time.Minute, log)
+	counter := concept.NewCircuitBreakerAnnotationsCounter(concept.NewAnnotationsCounter(d, 0, log), breaker)
+	_, err = counter.Count(context.Background(), []string{"601a5957-74ab-4eab-8a43-4596355c9420"})
+	require.Error(t, err)
+
+	h := newTestHealthService(d, breaker, log)
+	h.RunChecks()
+
+	req := httptest.NewRequest("GET", "/__health", nil)
+	w := httptest.NewRecorder()
+	h.HealthCheckHandleFunc()(w, req)
+
+	resp := w.Result()
+
+	var result fthealth.HealthResult
+	err = json.NewDecoder(resp.Body).Decode(&result)
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Checks, 5)
+	assert.False(t, result.Ok)
+
+	assert.False(t, result.Checks[1].Ok)
+	assert.Equal(t, "Neo4j circuit breaker is open", result.Checks[1].CheckOutput)
+}
+
 func TestHappyGTG(t *testing.T) {
 	log := logger.NewUPPLogger("test-neo4j-metric-aggregator", "warning")
 	neoTestURL := getNeoTestURL(t)
-	d, err := cmneo4j.NewDefaultDriver(neoTestURL, log)
+	d, err := neo.NewDriver(neoTestURL, log, neo.DefaultConfig())
 	require.NoError(t, err)
 
-	h := NewHealthService("", "", "", d)
+	writeTestSchemaAndContent(t, d, time.Now())
+
+	h := newTestHealthService(d, concept.NewCircuitBreaker(5, time.Minute, log), log)
+	h.RunChecks()
 
 	req := httptest.NewRequest("GET", "/__gtg", nil)
 	w := httptest.NewRecorder()
@@ -89,12 +138,71 @@ func TestHappyGTG(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 }
 
+func TestStaleContentHealthCheck(t *testing.T) {
+	log := logger.NewUPPLogger("test-neo4j-metric-aggregator", "warning")
+	neoTestURL := getNeoTestURL(t)
+	d, err := neo.NewDriver(neoTestURL, log, neo.DefaultConfig())
+	require.NoError(t, err)
+
+	writeTestSchemaAndContent(t, d, time.Now().Add(-48*time.Hour))
+
+	h := newTestHealthService(d, concept.NewCircuitBreaker(5, time.Minute, log), log)
+	h.RunChecks()
+
+	req := httptest.NewRequest("GET", "/__health", nil)
+	w := httptest.NewRecorder()
+	h.HealthCheckHandleFunc()(w, req)
+
+	var result fthealth.HealthResult
+	err = json.NewDecoder(w.Result().Body).Decode(&result)
+
+	assert.NoError(t, err)
+	assert.False(t, result.Ok)
+	assert.Equal(t, "check-content-freshness", result.Checks[4].ID)
+	assert.False(t, result.Checks[4].Ok)
+	assert.Contains(t, result.Checks[4].CheckOutput, "the newest content was published 48h")
+
+	// stale content is only a warning
+	w = httptest.NewRecorder()
+	status.NewGoodToGoHandler(h.GTG)(w, httptest.NewRequest("GET", "/__gtg", nil))
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+}
+
+func TestHealthCheckBeforeFirstRun(t *testing.T) {
+	log := logger.NewUPPLogger("test-neo4j-metric-aggregator", "warning")
+	neoTestURL := getNeoTestURL(t)
+	d, err := neo.NewDriver(neoTestURL, log, neo.DefaultConfig())
+	require.NoError(t, err)
+
+	h := newTestHealthService(d, concept.NewCircuitBreaker(5, time.Minute, log), log)
+
+	w := httptest.NewRecorder()
+	status.NewGoodToGoHandler(h.GTG)(w, httptest.NewRequest("GET", "/__gtg", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Result().StatusCode)
+}
+
+func TestSlowCanaryQueryHealthCheck(t *testing.T) {
+	log := logger.NewUPPLogger("test-neo4j-metric-aggregator", "warning")
+	neoTestURL := getNeoTestURL(t)
+	d, err := neo.NewDriver(neoTestURL, log, neo.DefaultConfig())
+	require.NoError(t, err)
+
+	config := testConfig
+	config.CanaryLatencyThreshold = time.Nanosecond
+	h := NewHealthService("", "", "", d, concept.NewAnnotationsCounter(d, 0, log), concept.NewCircuitBreaker(5, time.Minute, log), config)
+
+	output, err := h.canaryQueryChecker()
+	assert.Error(t, err)
+	assert.Contains(t, output, "over the 1ns threshold")
+}
+
 func TestUnhappyGTG(t *testing.T) {
 	log := logger.NewUPPLogger("test-neo4j-metric-aggregator", "warning")
-	d, err := cmneo4j.NewDefaultDriver("bolt://localhost:80", log)
+	d, err := neo.NewDriver("bolt://localhost:80", log, neo.DefaultConfig())
 	require.NoError(t, err)
 
-	h := NewHealthService("", "", "", d)
+	h := newTestHealthService(d, concept.NewCircuitBreaker(5, time.Minute, log), log)
+	h.RunChecks()
 
 	req := httptest.NewRequest("GET", "/__gtg", nil)
 	w := httptest.NewRecorder()
@@ -104,6 +212,37 @@ func TestUnhappyGTG(t *testing.T) {
 	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
 }
 
+var testConfig = Config{
+	CanaryConceptUUID:      "601a5957-74ab-4eab-8a43-4596355c9420",
+	CanaryLatencyThreshold: 5 * time.Second,
+	MaxContentAge:          24 * time.Hour,
+	CheckInterval:          10 * time.Second,
+	MaxResultAge:           time.Minute,
+}
+
+func newTestHealthService(d *neo.Driver, breaker *concept.CircuitBreaker, log *logger.UPPLogger) *HealthService {
+	return NewHealthService("", "", "", d, concept.NewAnnotationsCounter(d, 0, log), breaker, testConfig)
+}
+
+// writeTestSchemaAndContent creates the required indexes and a content published at the given time, deleted at
+// the end of the test.
+func writeTestSchemaAndContent(t *testing.T, d *neo.Driver, publishedAt time.Time) {
+	_, err := d.EnsureIndexes(context.Background(), concept.RequiredIndexes, time.Minute)
+	require.NoError(t, err)
+
+	contentUUID := uuid.New().String()
+	err = d.Write(&neo.Query{
+		Cypher: "CREATE (:Content{uuid:$uuid, publishedDateEpoch:$publishedDateEpoch})",
+		Params: map[string]interface{}{"uuid": contentUUID, "publishedDateEpoch": publishedAt.Unix()},
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err := d.Write(&neo.Query{Cypher: "MATCH (c:Content{uuid:$uuid}) DELETE c", Params: map[string]interface{}{"uuid": contentUUID}})
+		assert.NoError(t, err)
+	})
+}
+
 func getNeoTestURL(t *testing.T) string {
 	if testing.Short() {
 		t.Skip("Skipping Neo4j integration tests.")
@@ -0,0 +1,72 @@
+package healthcheck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
+	"github.com/Financial-Times/service-status-go/gtg"
+)
+
+func TestProbes(t *testing.T) {
+	h := newTestProbesHealthService()
+
+	assert.Equal(t, gtg.Status{GoodToGo: true}, h.Live())
+	assert.Equal(t, gtg.Status{GoodToGo: true}, h.Ready())
+
+	h.ShutDown()
+	assert.Equal(t, gtg.Status{GoodToGo: true}, h.Live())
+	assert.Equal(t, gtg.Status{GoodToGo: false, Message: "shutting down"}, h.Ready())
+}
+
+func TestReadyWhenNotGoodToGo(t *testing.T) {
+	h := newTestProbesHealthService()
+	h.cache.results["healthy"] = checkResult{err: assert.AnError, checkedAt: time.Now()}
+
+	assert.Equal(t, gtg.Status{GoodToGo: true}, h.Live())
+	assert.False(t, h.Ready().GoodToGo)
+}
+
+func newTestProbesHealthService() *HealthService {
+	check := fthealth.Check{ID: "healthy", Checker: func() (string, error) { return "all good", nil }}
+
+	h := &HealthService{}
+	h.cache = newCheckCache([]fthealth.Check{check}, time.Minute)
+	h.gtgChecks = []fthealth.Check{h.cache.cached(check)}
+	h.readyChecks = h.gtgChecks
+	h.RunChecks()
+	return h
+}
+
+func TestGTGChecks(t *testing.T) {
+	h := NewHealthService("", "", "", nil, nil, nil, Config{CheckInterval: time.Minute, MaxResultAge: time.Hour})
+	for _, check := range h.Checks {
+		h.cache.results[check.ID] = checkResult{checkedAt: time.Now()}
+	}
+	assert.Equal(t, gtg.Status{GoodToGo: true}, h.GTG())
+
+	// the circuit breaker only closes on a metrics request, which the service would not get when not good to go
+	h.cache.results[circuitBreakerCheckID] = checkResult{err: assert.AnError, checkedAt: time.Now()}
+	h.cache.results[freshnessCheckID] = checkResult{err: assert.AnError, checkedAt: time.Now()}
+	// the slow queries and the missing indexes affect all the instances at once
+	h.cache.results[canaryQueryCheckID] = checkResult{err: assert.AnError, checkedAt: time.Now()}
+	h.cache.results[indexesCheckID] = checkResult{err: assert.AnError, checkedAt: time.Now()}
+	assert.Equal(t, gtg.Status{GoodToGo: true}, h.GTG())
+
+	h.cache.results[neo4jCheckID] = checkResult{err: assert.AnError, checkedAt: time.Now()}
+	assert.False(t, h.GTG().GoodToGo)
+}
+
+func TestReadyChecks(t *testing.T) {
+	h := NewHealthService("", "", "", nil, nil, nil, Config{CheckInterval: time.Minute, MaxResultAge: time.Hour})
+	for _, check := range h.Checks {
+		h.cache.results[check.ID] = checkResult{err: assert.AnError, checkedAt: time.Now()}
+	}
+	h.cache.results[neo4jCheckID] = checkResult{checkedAt: time.Now()}
+	assert.Equal(t, gtg.Status{GoodToGo: true}, h.Ready())
+
+	h.cache.results[neo4jCheckID] = checkResult{err: assert.AnError, checkedAt: time.Now()}
+	assert.False(t, h.Ready().GoodToGo)
+}
@@ -7,19 +7,27 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	cli "github.com/jawher/mow.cli"
 	metrics "github.com/rcrowley/go-metrics"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
-	cmneo4j "github.com/Financial-Times/cm-neo4j-driver"
 	logger "github.com/Financial-Times/go-logger/v2"
 	"github.com/Financial-Times/http-handlers-go/v2/httphandlers"
+	"github.com/Financial-Times/neo4j-metric-aggregator/batch"
 	"github.com/Financial-Times/neo4j-metric-aggregator/concept"
+	"github.com/Financial-Times/neo4j-metric-aggregator/config"
 	"github.com/Financial-Times/neo4j-metric-aggregator/handlers"
 	"github.com/Financial-Times/neo4j-metric-aggregator/healthcheck"
+	"github.com/Financial-Times/neo4j-metric-aggregator/monitoring"
+	"github.com/Financial-Times/neo4j-metric-aggregator/neo"
+	"github.com/Financial-Times/neo4j-metric-aggregator/tracing"
 	status "github.com/Financial-Times/service-status-go/httphandlers"
 )
 
@@ -31,6 +39,9 @@ const (
 	httpServerWriteTimeout = 15 * time.Second
 	httpServerIdleTimeout  = 20 * time.Second
 	httpHandlersTimeout    = 14 * time.Second
+
+	livenessPath  = "/__live"
+	readinessPath = "/__ready"
 )
 
 func main() {
@@ -60,10 +71,81 @@ func main() {
 	neo4jEndpoint := app.String(cli.StringOpt{
 		Name:   "neo4j-endpoint",
 		Value:  "bolt://localhost:7687",
-		Desc:   "URL of the Neo4j bolt endpoint",
+		Desc:   "URL of the Neo4j endpoint, use the neo4j:// scheme to route the queries within a causal cluster",
 		EnvVar: "NEO4J_ENDPOINT",
 	})
 
+	neo4jDatabase := app.String(cli.StringOpt{
+		Name:   "neo4j-database",
+		Value:  "",
+		Desc:   "The Neo4j database to query, the server default database is used when empty",
+		EnvVar: "NEO4J_DATABASE",
+	})
+
+	neo4jUsername := app.String(cli.StringOpt{
+		Name:   "neo4j-username",
+		Value:  "",
+		Desc:   "The username to authenticate to Neo4j with, authentication is disabled when empty",
+		EnvVar: "NEO4J_USERNAME",
+	})
+
+	neo4jPassword := app.String(cli.StringOpt{
+		Name:      "neo4j-password",
+		Value:     "",
+		Desc:      "The password to authenticate to Neo4j with",
+		EnvVar:    "NEO4J_PASSWORD",
+		HideValue: true,
+	})
+
+	neo4jPasswordFile := app.String(cli.StringOpt{
+		Name:   "neo4j-password-file",
+		Value:  "",
+		Desc:   "File containing the password to authenticate to Neo4j with, takes precedence over neo4j-password",
+		EnvVar: "NEO4J_PASSWORD_FILE",
+	})
+
+	neo4jCABundle := app.String(cli.StringOpt{
+		Name:   "neo4j-ca-bundle",
+		Value:  "",
+		Desc:   "PEM file with the certificate authorities trusted for bolt+s and neo4j+s connections, the system ones are used when empty",
+		EnvVar: "NEO4J_CA_BUNDLE",
+	})
+
+	neo4jMaxConnections := app.Int(cli.IntOpt{
+		Name:   "neo4j-max-connections",
+		Value:  100,
+		Desc:   "The maximum number of parallel connections to Neo4j",
+		EnvVar: "NEO4J_MAX_CONNECTIONS",
+	})
+
+	neo4jConnectionAcquisitionTimeout := app.String(cli.StringOpt{
+		Name:   "neo4j-connection-acquisition-timeout",
+		Value:  "1m",
+		Desc:   "The maximum time to wait for a free connection to Neo4j when the pool is full",
+		EnvVar: "NEO4J_CONNECTION_ACQUISITION_TIMEOUT",
+	})
+
+	neo4jMaxTransactionRetryTime := app.String(cli.StringOpt{
+		Name:   "neo4j-max-transaction-retry-time",
+		Value:  "30s",
+		Desc:   "The maximum time a Neo4j transaction is retried for on transient errors, only applied when neo4j-retry-max-attempts is 1",
+		EnvVar: "NEO4J_MAX_TRANSACTION_RETRY_TIME",
+	})
+
+	neo4jTransactionTimeout := app.String(cli.StringOpt{
+		Name:   "neo4j-transaction-timeout",
+		Value:  "10s",
+		Desc:   "The time after which Neo4j terminates a transaction, 0 to use the server default",
+		EnvVar: "NEO4J_TRANSACTION_TIMEOUT",
+	})
+
+	neo4jFetchSize := app.Int(cli.IntOpt{
+		Name:   "neo4j-fetch-size",
+		Value:  1000,
+		Desc:   "The number of records fetched from Neo4j in each batch, -1 to fetch all records at once",
+		EnvVar: "NEO4J_FETCH_SIZE",
+	})
+
 	maxRequestBatchSize := app.Int(cli.IntOpt{
 		Name:   "maxRequestBatchSize",
 		Value:  1000,
@@ -71,39 +153,499 @@ func main() {
 		EnvVar: "MAX_REQUEST_BATCH_SIZE",
 	})
 
+	requestBudget := app.String(cli.StringOpt{
+		Name:   "request-budget",
+		Value:  "12s",
+		Desc:   fmt.Sprintf("The overall time allowed to compute the metrics of a request, it must be less than %v", httpHandlersTimeout),
+		EnvVar: "REQUEST_BUDGET",
+	})
+
+	retryMaxAttempts := app.Int(cli.IntOpt{
+		Name:   "neo4j-retry-max-attempts",
+		Value:  3,
+		Desc:   "The maximum number of attempts to count annotations on transient Neo4j errors, 1 to disable retries",
+		EnvVar: "NEO4J_RETRY_MAX_ATTEMPTS",
+	})
+
+	retryInitialBackoff := app.String(cli.StringOpt{
+		Name:   "neo4j-retry-initial-backoff",
+		Value:  "100ms",
+		Desc:   "The upper bound of the random delay before the first retry, doubled for every further retry",
+		EnvVar: "NEO4J_RETRY_INITIAL_BACKOFF",
+	})
+
+	retryMaxBackoff := app.String(cli.StringOpt{
+		Name:   "neo4j-retry-max-backoff",
+		Value:  "2s",
+		Desc:   "The maximum delay between retries",
+		EnvVar: "NEO4J_RETRY_MAX_BACKOFF",
+	})
+
+	circuitBreakerFailureThreshold := app.Int(cli.IntOpt{
+		Name:   "circuit-breaker-failure-threshold",
+		Value:  5,
+		Desc:   "The number of consecutive Neo4j failures after which the metrics requests are rejected without querying Neo4j",
+		EnvVar: "CIRCUIT_BREAKER_FAILURE_THRESHOLD",
+	})
+
+	circuitBreakerOpenTimeout := app.String(cli.StringOpt{
+		Name:   "circuit-breaker-open-timeout",
+		Value:  "30s",
+		Desc:   "The time after which Neo4j is tried again once the circuit breaker has opened",
+		EnvVar: "CIRCUIT_BREAKER_OPEN_TIMEOUT",
+	})
+
+	maxInFlightConcepts := app.Int(cli.IntOpt{
+		Name:   "max-in-flight-concepts",
+		Value:  5000,
+		Desc:   "The maximum number of concepts whose metrics are computed concurrently",
+		EnvVar: "MAX_IN_FLIGHT_CONCEPTS",
+	})
+
+	admissionQueueTimeout := app.String(cli.StringOpt{
+		Name:   "admission-queue-timeout",
+		Value:  "500ms",
+		Desc:   "The maximum time a request waits for the in-flight concepts to go below the limit before being rejected",
+		EnvVar: "ADMISSION_QUEUE_TIMEOUT",
+	})
+
+	rateLimitsFile := app.String(cli.StringOpt{
+		Name:   "rate-limits-file",
+		Value:  "",
+		Desc:   "JSON file with the rate limits of the clients identified by the X-Client-ID header, the requests are not rate limited when empty",
+		EnvVar: "RATE_LIMITS_FILE",
+	})
+
+	configFile := app.String(cli.StringOpt{
+		Name:   "config-file",
+		Value:  "",
+		Desc:   "YAML or JSON file overriding the maxRequestBatchSize, the request budget and the rate limits, reloaded with the rate limits file on SIGHUP",
+		EnvVar: "CONFIG_FILE",
+	})
+
+	apiKeys := app.String(cli.StringOpt{
+		Name:      "api-keys",
+		Value:     "",
+		Desc:      "Comma separated clientID:key or key API keys required to call the service endpoints, authentication is disabled when neither api-keys nor api-keys-file is set",
+		EnvVar:    "API_KEYS",
+		HideValue: true,
+	})
+
+	apiKeysFile := app.String(cli.StringOpt{
+		Name:   "api-keys-file",
+		Value:  "",
+		Desc:   "File with one clientID:key or key API key per line, reloaded when it changes",
+		EnvVar: "API_KEYS_FILE",
+	})
+
+	apiKeysReloadInterval := app.String(cli.StringOpt{
+		Name:   "api-keys-reload-interval",
+		Value:  "30s",
+		Desc:   "How often the API keys file is checked for changes",
+		EnvVar: "API_KEYS_RELOAD_INTERVAL",
+	})
+
+	adminAPIKeys := app.String(cli.StringOpt{
+		Name:      "admin-api-keys",
+		Value:     "",
+		Desc:      "Comma separated clientID:key or key API keys required to call the admin endpoints changing the log levels and serving the pprof profiles, the admin endpoints are disabled when empty",
+		EnvVar:    "ADMIN_API_KEYS",
+		HideValue: true,
+	})
+
+	slowQueryThreshold := app.String(cli.StringOpt{
+		Name:   "slow-query-threshold",
+		Value:  "1s",
+		Desc:   "The duration above which the query counting the annotations of a concept is logged with its UUID, slow queries are not logged when 0",
+		EnvVar: "SLOW_QUERY_THRESHOLD",
+	})
+
+	healthCanaryConceptUUID := app.String(cli.StringOpt{
+		Name:   "health-canary-concept-uuid",
+		Value:  "",
+		Desc:   "The concept whose metrics are computed by the healthcheck to measure the query latency, preferably one with many annotations, the latency is not checked when empty",
+		EnvVar: "HEALTH_CANARY_CONCEPT_UUID",
+	})
+
+	healthCanaryLatencyThreshold := app.String(cli.StringOpt{
+		Name:   "health-canary-latency-threshold",
+		Value:  "2s",
+		Desc:   "The time above which computing the metrics of the canary concept fails the healthcheck",
+		EnvVar: "HEALTH_CANARY_LATENCY_THRESHOLD",
+	})
+
+	healthMaxContentAge := app.String(cli.StringOpt{
+		Name:   "health-max-content-age",
+		Value:  "24h",
+		Desc:   "The age of the newest content above which the healthcheck warns that the knowledge base is stale",
+		EnvVar: "HEALTH_MAX_CONTENT_AGE",
+	})
+
+	healthCheckInterval := app.String(cli.StringOpt{
+		Name:   "health-check-interval",
+		Value:  "10s",
+		Desc:   "How often the healthchecks are run in the background, the health endpoints report their latest results",
+		EnvVar: "HEALTH_CHECK_INTERVAL",
+	})
+
+	healthMaxResultAge := app.String(cli.StringOpt{
+		Name:   "health-max-result-age",
+		Value:  "1m",
+		Desc:   "The age of the latest result of a healthcheck above which the check is reported as failed",
+		EnvVar: "HEALTH_MAX_RESULT_AGE",
+	})
+
+	schemaCheck := app.String(cli.StringOpt{
+		Name:   "schema-check",
+		Value:  schemaCheckWarn,
+		Desc:   "What to do on startup when the indexes the queries rely on are missing, either fail, warn or off",
+		EnvVar: "SCHEMA_CHECK",
+	})
+
+	shutdownDelay := app.String(cli.StringOpt{
+		Name:   "shutdown-delay",
+		Value:  "10s",
+		Desc:   "How long the service reports not ready on shutdown before draining the requests in flight, for the load balancers to stop routing requests to it",
+		EnvVar: "SHUTDOWN_DELAY",
+	})
+
+	otlpEndpoint := app.String(cli.StringOpt{
+		Name:   "otlp-endpoint",
+		Value:  "",
+		Desc:   "host:port of the OpenTelemetry collector receiving the traces over OTLP/HTTP, tracing is disabled when empty",
+		EnvVar: "OTLP_ENDPOINT",
+	})
+
+	otlpInsecure := app.Bool(cli.BoolOpt{
+		Name:   "otlp-insecure",
+		Value:  false,
+		Desc:   "Send the traces to the OpenTelemetry collector without TLS",
+		EnvVar: "OTLP_INSECURE",
+	})
+
+	traceSampleRatio := app.String(cli.StringOpt{
+		Name:   "trace-sample-ratio",
+		Value:  "1",
+		Desc:   "The ratio of the traces started by the service which are sampled, the traces continued from a traceparent header follow its sampling decision",
+		EnvVar: "TRACE_SAMPLE_RATIO",
+	})
+
 	log := logger.NewUPPInfoLogger(*appName)
-	dbLog := logger.NewUPPLogger(fmt.Sprintf("%s %s", *appName, "cmneo4j-driver"), "warning")
+	dbLog := logger.NewUPPLogger(fmt.Sprintf("%s %s", *appName, "neo4j-driver"), "warning")
+
+	neoConfig := func() neo.Config {
+		config, err := newNeoConfig(*neo4jMaxConnections, *neo4jConnectionAcquisitionTimeout, *neo4jMaxTransactionRetryTime, *neo4jTransactionTimeout, *neo4jFetchSize)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid Neo4j driver configuration")
+		}
+		if err = configureNeoSecurity(&config, *neo4jUsername, *neo4jPassword, *neo4jPasswordFile, *neo4jCABundle); err != nil {
+			log.WithError(err).Fatal("Invalid Neo4j authentication or TLS configuration")
+		}
+		config.DatabaseName = *neo4jDatabase
+		return config
+	}
+
+	retryingNeoConfig := func() (neo.Config, concept.RetryPolicy) {
+		policy, err := newRetryPolicy(*retryMaxAttempts, *retryInitialBackoff, *retryMaxBackoff)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid Neo4j retry configuration")
+		}
+		config := neoConfig()
+		if policy.MaxAttempts > 1 {
+			// the annotations counts are retried by the service, the driver must not retry them as well
+			config.MaxTransactionRetryTime = 0
+		}
+		return config, policy
+	}
+
+	newAnnotationsCounter := func(neoDriver *neo.Driver) concept.AnnotationsCounter {
+		threshold, err := time.ParseDuration(*slowQueryThreshold)
+		if err != nil || threshold < 0 {
+			log.WithField("slowQueryThreshold", *slowQueryThreshold).Fatal("Invalid slow query threshold")
+		}
+		return concept.NewAnnotationsCounter(neoDriver, threshold, log)
+	}
+
+	newNeoDriver := func(config neo.Config) *neo.Driver {
+		neoDriver, err := neo.NewDriver(*neo4jEndpoint, dbLog, config)
+		if err != nil {
+			log.WithField("neo4jURL", *neo4jEndpoint).
+				WithError(err).
+				Fatal("Could not initiate neo4j driver")
+		}
+		return neoDriver
+	}
 
 	app.Action = func() {
+		config, policy := retryingNeoConfig()
+
 		log.WithFields(map[string]interface{}{
-			"appName":             *appName,
-			"appSystemCode":       *appSystemCode,
-			"port":                *port,
-			"neo4jEndpoint":       *neo4jEndpoint,
-			"maxRequestBatchSize": *maxRequestBatchSize,
+			"appName":                           *appName,
+			"appSystemCode":                     *appSystemCode,
+			"port":                              *port,
+			"neo4jEndpoint":                     *neo4jEndpoint,
+			"neo4jDatabase":                     *neo4jDatabase,
+			"neo4jUsername":                     *neo4jUsername,
+			"neo4jCABundle":                     *neo4jCABundle,
+			"neo4jMaxConnections":               config.MaxConnectionPoolSize,
+			"neo4jConnectionAcquisitionTimeout": config.ConnectionAcquisitionTimeout.String(),
+			"neo4jMaxTransactionRetryTime":      config.MaxTransactionRetryTime.String(),
+			"neo4jTransactionTimeout":           config.TransactionTimeout.String(),
+			"neo4jFetchSize":                    config.FetchSize,
+			"maxRequestBatchSize":               *maxRequestBatchSize,
+			"requestBudget":                     *requestBudget,
+			"neo4jRetryMaxAttempts":             policy.MaxAttempts,
+			"neo4jRetryInitialBackoff":          policy.InitialBackoff.String(),
+			"neo4jRetryMaxBackoff":              policy.MaxBackoff.String(),
+			"circuitBreakerFailureThreshold":    *circuitBreakerFailureThreshold,
+			"circuitBreakerOpenTimeout":         *circuitBreakerOpenTimeout,
+			"maxInFlightConcepts":               *maxInFlightConcepts,
+			"admissionQueueTimeout":             *admissionQueueTimeout,
+			"rateLimitsFile":                    *rateLimitsFile,
+			"configFile":                        *configFile,
+			"apiKeysFile":                       *apiKeysFile,
+			"apiKeysReloadInterval":             *apiKeysReloadInterval,
+			"adminEndpointsEnabled":             *adminAPIKeys != "",
+			"slowQueryThreshold":                *slowQueryThreshold,
+			"healthCanaryConceptUUID":           *healthCanaryConceptUUID,
+			"healthCanaryLatencyThreshold":      *healthCanaryLatencyThreshold,
+			"healthMaxContentAge":               *healthMaxContentAge,
+			"healthCheckInterval":               *healthCheckInterval,
+			"healthMaxResultAge":                *healthMaxResultAge,
+			"schemaCheck":                       *schemaCheck,
+			"shutdownDelay":                     *shutdownDelay,
+			"otlpEndpoint":                      *otlpEndpoint,
+			"otlpInsecure":                      *otlpInsecure,
+			"traceSampleRatio":                  *traceSampleRatio,
 		}).Infof("[Startup] %v is starting", *appSystemCode)
 
-		neoDriver, err := cmneo4j.NewDefaultDriver(*neo4jEndpoint, dbLog)
+		settings, err := loadSettings(*maxRequestBatchSize, *requestBudget, *rateLimitsFile, *configFile)
 		if err != nil {
-			log.WithField("neo4jURL", *neo4jEndpoint).
-				WithError(err).
-				Fatal("Could not initiate cmneo4j driver")
+			log.WithError(err).Fatal("Invalid configuration")
 		}
 
-		aggregator := concept.NewMetricsAggregator(neoDriver, log)
-		h := handlers.NewConceptsMetricsHandler(aggregator, *maxRequestBatchSize, log)
+		breaker, err := newCircuitBreaker(*circuitBreakerFailureThreshold, *circuitBreakerOpenTimeout, log)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid circuit breaker configuration")
+		}
+
+		healthConfig, err := newHealthConfig(*healthCanaryConceptUUID, *healthCanaryLatencyThreshold, *healthMaxContentAge, *healthCheckInterval, *healthMaxResultAge)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid healthcheck configuration")
+		}
+
+		if err := validateSchemaCheck(*schemaCheck); err != nil {
+			log.WithError(err).Fatal("Invalid schema check")
+		}
+
+		drainDelay, err := time.ParseDuration(*shutdownDelay)
+		if err != nil || drainDelay < 0 {
+			log.WithField("shutdownDelay", *shutdownDelay).Fatal("Invalid shutdown delay")
+		}
+
+		queueTimeout, err := parseAdmissionSettings(*maxInFlightConcepts, *admissionQueueTimeout)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid admission control configuration")
+		}
 
-		healthSvc := healthcheck.NewHealthService(*appSystemCode, *appName, appDescription, neoDriver)
+		// the rate limits can be set on reload when either file is given
+		var rateLimiter *handlers.RateLimiter
+		if *rateLimitsFile != "" || *configFile != "" {
+			rateLimiter = handlers.NewRateLimiter(settings.RateLimits, log)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
 
-		router := registerEndpoints(h, healthSvc, log)
+		var authenticator *handlers.Authenticator
+		if *apiKeys != "" || *apiKeysFile != "" {
+			reloadInterval, err := time.ParseDuration(*apiKeysReloadInterval)
+			if err != nil || reloadInterval <= 0 {
+				log.WithField("apiKeysReloadInterval", *apiKeysReloadInterval).Fatal("Invalid API keys reload interval")
+			}
+			authenticator, err = handlers.NewAuthenticator(*apiKeys, *apiKeysFile, log)
+			if err != nil {
+				log.WithError(err).Fatal("Invalid API keys")
+			}
+			if *apiKeysFile != "" {
+				go authenticator.WatchFile(ctx, reloadInterval)
+			}
+		}
+
+		var adminAuthenticator *handlers.Authenticator
+		if *adminAPIKeys != "" {
+			adminAuthenticator, err = handlers.NewAuthenticator(*adminAPIKeys, "", log)
+			if err != nil {
+				log.WithError(err).Fatal("Invalid admin API keys")
+			}
+		}
+
+		exporter, sampleRatio, err := newTraceExporter(ctx, *otlpEndpoint, *otlpInsecure, *traceSampleRatio)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid tracing configuration")
+		}
+		shutdownTracing := tracing.Setup(*appSystemCode, exporter, sampleRatio)
+
+		neoDriver := newNeoDriver(config)
+		checkSchema(ctx, neoDriver, *schemaCheck, log)
+
+		neoCounter := newAnnotationsCounter(neoDriver)
+		counter := concept.NewRetryingAnnotationsCounter(neoCounter, policy, metrics.DefaultRegistry, log)
+		counter = concept.NewCircuitBreakerAnnotationsCounter(counter, breaker)
+		aggregator := concept.NewMetricsAggregator(neoDriver, counter, log)
+		aggregator = concept.NewLimitedMetricsAggregator(aggregator, int64(*maxInFlightConcepts), queueTimeout, metrics.DefaultRegistry, log)
+		h := handlers.NewConceptsMetricsHandler(aggregator, settings.MaxRequestBatchSize, settings.RequestBudget, log)
+
+		healthSvc := healthcheck.NewHealthService(*appSystemCode, *appName, appDescription, neoDriver, neoCounter, breaker, healthConfig)
+		go healthSvc.Start(ctx)
+
+		promRegistry := monitoring.NewRegistry(metrics.DefaultRegistry, concept.Collectors()...)
+
+		var adminHandler http.Handler
+		if adminAuthenticator != nil {
+			loggers := map[string]*logger.UPPLogger{"app": log, "neo4j-driver": dbLog}
+			// the admin requests are logged for auditing, and the write deadline is only extended once authenticated
+			adminHandler = adminAuthenticator.Handler(handlers.ExtendProfileWriteDeadline(
+				httphandlers.TransactionAwareRequestLoggingHandler(log, handlers.NewAdminHandler(loggers, log))))
+		}
+
+		router := registerEndpoints(h, healthSvc, monitoring.Handler(promRegistry), adminHandler, authenticator, rateLimiter, log)
 
 		server := newHTTPServer(*port, router)
 		go startHTTPServer(server, log)
 
-		waitForSignal()
+		waitForSignal(func() {
+			reloaded, err := loadSettings(*maxRequestBatchSize, *requestBudget, *rateLimitsFile, *configFile)
+			if err != nil {
+				log.WithError(err).Error("Failed reloading the configuration, the previous settings are still in use")
+				return
+			}
+			h.SetLimits(reloaded.MaxRequestBatchSize, reloaded.RequestBudget)
+			if rateLimiter != nil {
+				rateLimiter.SetLimits(reloaded.RateLimits)
+			}
+			log.WithField("maxRequestBatchSize", reloaded.MaxRequestBatchSize).
+				WithField("requestBudget", reloaded.RequestBudget.String()).
+				Info("Reloaded the configuration")
+		})
+		healthSvc.ShutDown()
+		log.Infof("Service is not ready anymore, draining the requests in %v", drainDelay)
+		time.Sleep(drainDelay)
 		stopHTTPServer(server, log)
+		stopTracing(shutdownTracing, log)
 	}
 
+	app.Command("compute", "Computes metrics for concept UUIDs read from a file or stdin", func(cmd *cli.Cmd) {
+		input := cmd.String(cli.StringOpt{
+			Name:  "input",
+			Value: "-",
+			Desc:  "File with one concept UUID per line, - for stdin",
+		})
+		output := cmd.String(cli.StringOpt{
+			Name:  "output",
+			Value: "-",
+			Desc:  "File to write the metrics to, - for stdout",
+		})
+		format := cmd.String(cli.StringOpt{
+			Name:  "format",
+			Value: string(batch.FormatJSONLines),
+			Desc:  "Output format, either jsonl (JSON lines) or csv",
+		})
+		chunkSize := cmd.Int(cli.IntOpt{
+			Name:  "chunk-size",
+			Value: 1000,
+			Desc:  "The number of concepts to compute metrics for at once",
+		})
+		checkpointFile := cmd.String(cli.StringOpt{
+			Name:  "checkpoint",
+			Value: "",
+			Desc:  "File to save the progress to, so that an interrupted computation is resumed from where it stopped",
+		})
+
+		cmd.Action = func() {
+			config, policy := retryingNeoConfig()
+
+			log.WithFields(map[string]interface{}{
+				"neo4jEndpoint":                     *neo4jEndpoint,
+				"neo4jDatabase":                     *neo4jDatabase,
+				"neo4jUsername":                     *neo4jUsername,
+				"neo4jCABundle":                     *neo4jCABundle,
+				"neo4jMaxConnections":               config.MaxConnectionPoolSize,
+				"neo4jConnectionAcquisitionTimeout": config.ConnectionAcquisitionTimeout.String(),
+				"neo4jMaxTransactionRetryTime":      config.MaxTransactionRetryTime.String(),
+				"neo4jTransactionTimeout":           config.TransactionTimeout.String(),
+				"neo4jFetchSize":                    config.FetchSize,
+				"neo4jRetryMaxAttempts":             policy.MaxAttempts,
+				"neo4jRetryInitialBackoff":          policy.InitialBackoff.String(),
+				"neo4jRetryMaxBackoff":              policy.MaxBackoff.String(),
+				"slowQueryThreshold":                *slowQueryThreshold,
+				"input":                             *input,
+				"output":                            *output,
+				"format":                            *format,
+				"chunkSize":                         *chunkSize,
+				"checkpoint":                        *checkpointFile,
+			}).Infof("[Startup] %v is computing metrics", *appSystemCode)
+
+			neoDriver := newNeoDriver(config)
+
+			counter := concept.NewRetryingAnnotationsCounter(newAnnotationsCounter(neoDriver), policy, metrics.DefaultRegistry, log)
+			aggregator := concept.NewMetricsAggregator(neoDriver, counter, log)
+			computer, err := batch.NewComputer(aggregator, *chunkSize, batch.Format(*format), log)
+			if err != nil {
+				log.WithError(err).Fatal("Invalid compute options")
+			}
+
+			err = runCompute(computer, *input, *output, *checkpointFile)
+			if errors.Is(err, context.Canceled) {
+				// the output is incomplete, but the computation can be resumed from the last checkpoint
+				log.WithField("checkpoint", *checkpointFile).Warn("Computation interrupted after the chunk in progress was written")
+				os.Exit(1)
+			}
+			if err != nil {
+				log.WithError(err).Fatal("Failed computing metrics")
+			}
+		}
+	})
+
+	app.Command("ensure-indexes", "Creates the indexes the queries rely on, if they do not exist", func(cmd *cli.Cmd) {
+		timeout := cmd.String(cli.StringOpt{
+			Name:  "timeout",
+			Value: "5m",
+			Desc:  "The maximum time to wait for the created and populating indexes to be online",
+		})
+
+		cmd.Action = func() {
+			config := neoConfig()
+			// populating the indexes can take longer than the transaction timeout of the queries
+			config.TransactionTimeout = 0
+
+			log.WithFields(map[string]interface{}{
+				"neo4jEndpoint": *neo4jEndpoint,
+				"neo4jDatabase": *neo4jDatabase,
+				"neo4jUsername": *neo4jUsername,
+				"neo4jCABundle": *neo4jCABundle,
+				"timeout":       *timeout,
+			}).Infof("[Startup] %v is ensuring the indexes exist", *appSystemCode)
+
+			awaitTimeout, err := time.ParseDuration(*timeout)
+			if err != nil || awaitTimeout <= 0 {
+				log.WithField("timeout", *timeout).Fatal("Invalid timeout")
+			}
+
+			neoDriver := newNeoDriver(config)
+			defer neoDriver.Close()
+
+			created, err := neoDriver.EnsureIndexes(context.Background(), concept.RequiredIndexes, awaitTimeout)
+			for _, index := range created {
+				log.WithField("index", index.String()).Info("Created index")
+			}
+			if err != nil {
+				log.WithError(err).Fatal("Failed ensuring the indexes exist")
+			}
+			log.Infof("All the %v required indexes are online", len(concept.RequiredIndexes))
+		}
+	})
+
 	if err := app.Run(os.Args); err != nil {
 		log.Errorf("App could not start, error=[%s]\n", err)
 		return
@@ -111,22 +653,318 @@ func main() {
 
 }
 
-func registerEndpoints(handler *handlers.ConceptsMetricsHandler, healthService *healthcheck.HealthService, log *logger.UPPLogger) http.Handler {
+func newNeoConfig(maxConnections int, connectionAcquisitionTimeout string, maxTransactionRetryTime string, transactionTimeout string, fetchSize int) (neo.Config, error) {
+	config := neo.DefaultConfig()
+
+	if maxConnections < 1 {
+		return config, fmt.Errorf("neo4j max connections must be positive, got %v", maxConnections)
+	}
+	config.MaxConnectionPoolSize = maxConnections
+
+	var err error
+	if config.ConnectionAcquisitionTimeout, err = time.ParseDuration(connectionAcquisitionTimeout); err != nil {
+		return config, fmt.Errorf("invalid neo4j connection acquisition timeout: %w", err)
+	}
+	if config.MaxTransactionRetryTime, err = time.ParseDuration(maxTransactionRetryTime); err != nil {
+		return config, fmt.Errorf("invalid neo4j max transaction retry time: %w", err)
+	}
+	if config.TransactionTimeout, err = time.ParseDuration(transactionTimeout); err != nil {
+		return config, fmt.Errorf("invalid neo4j transaction timeout: %w", err)
+	}
+	if config.TransactionTimeout < 0 {
+		return config, fmt.Errorf("neo4j transaction timeout must not be negative, got %v", config.TransactionTimeout)
+	}
+
+	if fetchSize == 0 || fetchSize < -1 {
+		return config, fmt.Errorf("neo4j fetch size must be positive or -1, got %v", fetchSize)
+	}
+	config.FetchSize = fetchSize
+
+	return config, nil
+}
+
+// loadSettings reads the settings which can be changed while the service is running from the flags, the rate
+// limits file and the config file, in increasing order of precedence.
+func loadSettings(maxRequestBatchSize int, requestBudget string, rateLimitsFile string, configFile string) (config.Settings, error) {
+	budget, err := parseRequestBudget(requestBudget)
+	if err != nil {
+		return config.Settings{}, err
+	}
+	settings := config.Settings{MaxRequestBatchSize: maxRequestBatchSize, RequestBudget: budget}
+
+	if rateLimitsFile != "" {
+		if settings.RateLimits, err = handlers.LoadRateLimits(rateLimitsFile); err != nil {
+			return settings, err
+		}
+	}
+
+	if configFile != "" {
+		file, err := config.Load(configFile)
+		if err != nil {
+			return settings, err
+		}
+		if settings, err = file.Merge(settings); err != nil {
+			return settings, err
+		}
+	}
+	return settings, settings.Validate(httpHandlersTimeout)
+}
+
+// parseRequestBudget parses the overall time allowed to a request, which must expire before the HTTP handlers
+// timeout for the timed out requests to be answered with 504 rather than 503.
+func parseRequestBudget(value string) (time.Duration, error) {
+	budget, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if budget <= 0 || budget >= httpHandlersTimeout {
+		return 0, fmt.Errorf("request budget must be positive and less than %v, got %v", httpHandlersTimeout, budget)
+	}
+	return budget, nil
+}
+
+func newRetryPolicy(maxAttempts int, initialBackoff string, maxBackoff string) (concept.RetryPolicy, error) {
+	policy := concept.RetryPolicy{MaxAttempts: maxAttempts}
+	if maxAttempts < 1 {
+		return policy, fmt.Errorf("neo4j retry max attempts must be positive, got %v", maxAttempts)
+	}
+
+	var err error
+	if policy.InitialBackoff, err = time.ParseDuration(initialBackoff); err != nil {
+		return policy, fmt.Errorf("invalid neo4j retry initial backoff: %w", err)
+	}
+	if policy.MaxBackoff, err = time.ParseDuration(maxBackoff); err != nil {
+		return policy, fmt.Errorf("invalid neo4j retry max backoff: %w", err)
+	}
+	if policy.InitialBackoff <= 0 || policy.MaxBackoff < policy.InitialBackoff {
+		return policy, fmt.Errorf("neo4j retry backoffs must be positive and the max backoff not less than the initial one, got %v and %v", policy.InitialBackoff, policy.MaxBackoff)
+	}
+
+	return policy, nil
+}
+
+func newCircuitBreaker(failureThreshold int, openTimeout string, log *logger.UPPLogger) (*concept.CircuitBreaker, error) {
+	if failureThreshold < 1 {
+		return nil, fmt.Errorf("circuit breaker failure threshold must be positive, got %v", failureThreshold)
+	}
+	timeout, err := time.ParseDuration(openTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid circuit breaker open timeout: %w", err)
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("circuit breaker open timeout must be positive, got %v", timeout)
+	}
+	return concept.NewCircuitBreaker(failureThreshold, timeout, log), nil
+}
+
+// parseAdmissionSettings validates the concurrency limit and parses the time the requests are queued for.
+func parseAdmissionSettings(maxInFlightConcepts int, queueTimeout string) (time.Duration, error) {
+	if maxInFlightConcepts < 1 {
+		return 0, fmt.Errorf("max in-flight concepts must be positive, got %v", maxInFlightConcepts)
+	}
+	timeout, err := time.ParseDuration(queueTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid admission queue timeout: %w", err)
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("admission queue timeout must not be negative, got %v", timeout)
+	}
+	return timeout, nil
+}
+
+// newHealthConfig validates the canary concept UUID, if any, and parses the thresholds and the schedule of the
+// healthchecks.
+func newHealthConfig(canaryConceptUUID string, canaryLatencyThreshold string, maxContentAge string, checkInterval string, maxResultAge string) (healthcheck.Config, error) {
+	if canaryConceptUUID != "" {
+		if _, err := uuid.Parse(canaryConceptUUID); err != nil {
+			return healthcheck.Config{}, fmt.Errorf("invalid canary concept UUID: %w", err)
+		}
+	}
+	threshold, err := time.ParseDuration(canaryLatencyThreshold)
+	if err != nil || threshold <= 0 {
+		return healthcheck.Config{}, fmt.Errorf("canary latency threshold must be a positive duration, got %q", canaryLatencyThreshold)
+	}
+	age, err := time.ParseDuration(maxContentAge)
+	if err != nil || age <= 0 {
+		return healthcheck.Config{}, fmt.Errorf("max content age must be a positive duration, got %q", maxContentAge)
+	}
+	interval, err := time.ParseDuration(checkInterval)
+	if err != nil || interval <= 0 {
+		return healthcheck.Config{}, fmt.Errorf("health check interval must be a positive duration, got %q", checkInterval)
+	}
+	resultAge, err := time.ParseDuration(maxResultAge)
+	if err != nil || resultAge <= interval {
+		return healthcheck.Config{}, fmt.Errorf("max health check result age must be a duration longer than the check interval, got %q", maxResultAge)
+	}
+	return healthcheck.Config{
+		CanaryConceptUUID:      canaryConceptUUID,
+		CanaryLatencyThreshold: threshold,
+		MaxContentAge:          age,
+		CheckInterval:          interval,
+		MaxResultAge:           resultAge,
+	}, nil
+}
+
+const (
+	schemaCheckFail = "fail"
+	schemaCheckWarn = "warn"
+	schemaCheckOff  = "off"
+)
+
+func validateSchemaCheck(mode string) error {
+	switch mode {
+	case schemaCheckFail, schemaCheckWarn, schemaCheckOff:
+		return nil
+	}
+	return fmt.Errorf("schema check must be one of %v, %v or %v, got %q", schemaCheckFail, schemaCheckWarn, schemaCheckOff, mode)
+}
+
+// checkSchema verifies that the indexes the queries rely on exist, failing or warning as set by mode when they
+// do not or cannot be looked up.
+func checkSchema(ctx context.Context, neoDriver *neo.Driver, mode string, log *logger.UPPLogger) {
+	if mode == schemaCheckOff {
+		return
+	}
+
+	missing, err := neoDriver.MissingIndexes(ctx, concept.RequiredIndexes)
+	if err == nil && len(missing) == 0 {
+		return
+	}
+
+	entry := log.WithError(err)
+	msg := "Failed verifying the Neo4j schema"
+	if err == nil {
+		entry = log.WithField("missingIndexes", fmt.Sprint(missing))
+		msg = "The Neo4j indexes the queries rely on are missing or not online yet, run the ensure-indexes command to create them and wait for them to be online"
+	}
+	if mode == schemaCheckFail {
+		entry.Fatal(msg)
+	}
+	entry.Warn(msg)
+}
+
+// newTraceExporter creates the exporter sending the traces to the OpenTelemetry collector at endpoint, and parses
+// the ratio of the traces sampled. The exporter is nil, disabling tracing, when endpoint is empty.
+func newTraceExporter(ctx context.Context, endpoint string, insecure bool, sampleRatio string) (sdktrace.SpanExporter, float64, error) {
+	ratio, err := strconv.ParseFloat(sampleRatio, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid trace sample ratio: %w", err)
+	}
+	if ratio < 0 || ratio > 1 {
+		return nil, 0, fmt.Errorf("trace sample ratio must be between 0 and 1, got %v", ratio)
+	}
+	if endpoint == "" {
+		return nil, ratio, nil
+	}
+	exporter, err := tracing.NewOTLPExporter(ctx, endpoint, insecure)
+	return exporter, ratio, err
+}
+
+// configureNeoSecurity sets the Neo4j credentials and the trusted certificate authorities. The password is
+// read from passwordFile, when given, so that it can be mounted from a secret instead of set in the environment.
+func configureNeoSecurity(config *neo.Config, username string, password string, passwordFile string, caBundle string) error {
+	if passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return fmt.Errorf("failed reading neo4j password file: %w", err)
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+	}
+	if username == "" && password != "" {
+		return errors.New("neo4j password is set without a username")
+	}
+	config.Username = username
+	config.Password = password
+
+	if caBundle != "" {
+		rootCAs, err := neo.LoadCABundle(caBundle)
+		if err != nil {
+			return err
+		}
+		config.RootCAs = rootCAs
+	}
+
+	return nil
+}
+
+// runCompute resumes the computation from the checkpoint, if any, and stops it gracefully on SIGINT or SIGTERM
+// after the chunk in progress is written, returning context.Canceled.
+func runCompute(computer *batch.Computer, input string, output string, checkpointFile string) error {
+	skip := 0
+	var checkpoint batch.CheckpointFunc
+	if checkpointFile != "" {
+		var err error
+		if skip, err = batch.ReadCheckpoint(checkpointFile); err != nil {
+			return err
+		}
+		checkpoint = func(processed int) error {
+			return batch.WriteCheckpoint(checkpointFile, processed)
+		}
+	}
+
+	in := os.Stdin
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	out := os.Stdout
+	if output != "-" {
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if skip > 0 {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		f, err := os.OpenFile(output, flags, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	_, err := computer.Compute(ctx, in, out, skip, checkpoint)
+	return err
+}
+
+func registerEndpoints(handler *handlers.ConceptsMetricsHandler, healthService *healthcheck.HealthService, metricsHandler http.Handler, adminHandler http.Handler, authenticator *handlers.Authenticator, rateLimiter *handlers.RateLimiter, log *logger.UPPLogger) http.Handler {
 	serveMux := http.NewServeMux()
 
 	// register supervisory endpoint that does not require logging and metrics collection
 	serveMux.HandleFunc("/__health", healthService.HealthCheckHandleFunc())
 	serveMux.HandleFunc(status.GTGPath, status.NewGoodToGoHandler(healthService.GTG))
+	serveMux.HandleFunc(livenessPath, status.NewGoodToGoHandler(healthService.Live))
+	serveMux.HandleFunc(readinessPath, status.NewGoodToGoHandler(healthService.Ready))
 	serveMux.HandleFunc(status.BuildInfoPath, status.BuildInfoHandler)
+	serveMux.Handle("/metrics", metricsHandler)
+
+	// the admin endpoints are protected with their own API keys
+	if adminHandler != nil {
+		serveMux.Handle(handlers.AdminPathPrefix+"/", adminHandler)
+	}
 
 	// add services router and register endpoints specific to this service only
 	servicesRouter := mux.NewRouter()
 	servicesRouter.HandleFunc("/concepts/metrics", handler.GetMetrics).Methods("GET")
+	servicesRouter.HandleFunc("/concepts/orphans", handler.GetOrphans).Methods("GET")
+	servicesRouter.HandleFunc("/metrics/summary", handler.GetSummary).Methods("GET")
 
-	// wrap the handlers with certain middlewares providing logging of the requests,
-	// sending metrics and handler time out on certain time interval
-	var wrappedServicesRouter http.Handler = servicesRouter
-	wrappedServicesRouter = httphandlers.TransactionAwareRequestLoggingHandler(log, wrappedServicesRouter)
+	// wrap the handlers with certain middlewares providing trace propagation, authentication, rate limiting and
+	// logging of the requests, sending metrics and handler time out on certain time interval
+	var wrappedServicesRouter http.Handler = tracing.Handler(servicesRouter)
+	if rateLimiter != nil {
+		wrappedServicesRouter = rateLimiter.Handler(wrappedServicesRouter)
+	}
+	if authenticator != nil {
+		wrappedServicesRouter = authenticator.Handler(wrappedServicesRouter)
+	}
+	wrappedServicesRouter = handler.RequestLoggingHandler(wrappedServicesRouter)
 	wrappedServicesRouter = httphandlers.HTTPMetricsHandler(metrics.DefaultRegistry, wrappedServicesRouter)
 	wrappedServicesRouter = http.TimeoutHandler(wrappedServicesRouter, httpHandlersTimeout, "")
 
@@ -162,8 +1000,25 @@ func stopHTTPServer(server *http.Server, log *logger.UPPLogger) {
 	}
 }
 
-func waitForSignal() {
+func stopTracing(shutdown func(ctx context.Context) error, log *logger.UPPLogger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := shutdown(ctx); err != nil {
+		log.WithError(err).Error("Failed to export the remaining traces")
+	}
+}
+
+// waitForSignal waits for SIGINT or SIGTERM, calling reload on every SIGHUP received meanwhile.
+func waitForSignal(reload func()) {
 	ch := make(chan os.Signal, 1)
-	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
-	<-ch
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(ch)
+
+	for sig := range ch {
+		if sig != syscall.SIGHUP {
+			return
+		}
+		reload()
+	}
 }
@@ -0,0 +1,77 @@
+package monitoring
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+var quantiles = []float64{0.5, 0.95, 0.99}
+
+// goMetricsCollector exposes the metrics of a go-metrics registry, such as the HTTP timers and the Neo4j retry
+// counters, as Prometheus metrics prefixed with Namespace. Counters and meters become counters suffixed with
+// _total, gauges become gauges, and timers and histograms become summaries, the timers in seconds.
+type goMetricsCollector struct {
+	registry metrics.Registry
+}
+
+// NewGoMetricsCollector creates a collector of the metrics of the given go-metrics registry.
+func NewGoMetricsCollector(registry metrics.Registry) prometheus.Collector {
+	return &goMetricsCollector{registry: registry}
+}
+
+// Describe sends no descriptors, as the metrics of the registry are only known when collected.
+func (c *goMetricsCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c *goMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.registry.Each(func(name string, i interface{}) {
+		name = Namespace + "_" + metricName(name)
+		switch m := i.(type) {
+		case metrics.Counter:
+			ch <- constMetric(name+"_total", prometheus.CounterValue, float64(m.Count()))
+		case metrics.Meter:
+			ch <- constMetric(name+"_total", prometheus.CounterValue, float64(m.Snapshot().Count()))
+		case metrics.Gauge:
+			ch <- constMetric(name, prometheus.GaugeValue, float64(m.Value()))
+		case metrics.GaugeFloat64:
+			ch <- constMetric(name, prometheus.GaugeValue, m.Value())
+		case metrics.Timer:
+			s := m.Snapshot()
+			seconds := float64(time.Second)
+			ch <- constSummary(name+"_duration_seconds", s.Count(), float64(s.Sum())/seconds, s.Percentiles(quantiles), seconds)
+		case metrics.Histogram:
+			s := m.Snapshot()
+			ch <- constSummary(name, s.Count(), float64(s.Sum()), s.Percentiles(quantiles), 1)
+		}
+	})
+}
+
+func constMetric(name string, valueType prometheus.ValueType, value float64) prometheus.Metric {
+	desc := prometheus.NewDesc(name, "go-metrics "+name, nil, nil)
+	return prometheus.MustNewConstMetric(desc, valueType, value)
+}
+
+func constSummary(name string, count int64, sum float64, percentiles []float64, unit float64) prometheus.Metric {
+	values := make(map[float64]float64, len(quantiles))
+	for i, q := range quantiles {
+		values[q] = percentiles[i] / unit
+	}
+	desc := prometheus.NewDesc(name, "go-metrics "+name, nil, nil)
+	return prometheus.MustNewConstSummary(desc, uint64(count), sum, values)
+}
+
+// metricName turns a go-metrics name, such as neo4j.retries, into a valid Prometheus name, such as neo4j_retries.
+func metricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '_'
+		}
+	}, name)
+}
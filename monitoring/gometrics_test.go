@@ -0,0 +1,48 @@
+package monitoring
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricName(t *testing.T) {
+	assert.Equal(t, "neo4j_retries", metricName("neo4j.retries"))
+	assert.Equal(t, "get", metricName("GET"))
+	assert.Equal(t, "concepts_metrics_queue_depth", metricName("concepts.metrics.queue.depth"))
+}
+
+func TestHandlerExposesGoMetrics(t *testing.T) {
+	goMetrics := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("neo4j.retries", goMetrics).Inc(3)
+	metrics.GetOrRegisterGauge("queue.depth", goMetrics).Update(2)
+	metrics.GetOrRegisterTimer("GET", goMetrics).Update(500 * time.Millisecond)
+
+	server := httptest.NewServer(Handler(NewRegistry(goMetrics)))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	for _, line := range []string{
+		"neo4j_metric_aggregator_neo4j_retries_total 3",
+		"neo4j_metric_aggregator_queue_depth 2",
+		"neo4j_metric_aggregator_get_duration_seconds_count 1",
+		"neo4j_metric_aggregator_get_duration_seconds_sum 0.5",
+		`neo4j_metric_aggregator_get_duration_seconds{quantile="0.5"} 0.5`,
+	} {
+		assert.True(t, strings.Contains(string(body), line), "missing %q", line)
+	}
+	assert.True(t, strings.Contains(string(body), "go_goroutines"))
+}
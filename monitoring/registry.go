@@ -0,0 +1,32 @@
+package monitoring
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// Namespace prefixes the names of the metrics of the service, to tell them apart from the ones of other services
+// scraped by the same Prometheus.
+const Namespace = "neo4j_metric_aggregator"
+
+// NewRegistry creates a Prometheus registry gathering the given collectors, the metrics of the given go-metrics
+// registry and the Go runtime and process metrics.
+func NewRegistry(goMetrics metrics.Registry, cs ...prometheus.Collector) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		NewGoMetricsCollector(goMetrics),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	registry.MustRegister(cs...)
+	return registry
+}
+
+// Handler serves the metrics of the given registry in the Prometheus exposition format.
+func Handler(registry *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
@@ -0,0 +1,54 @@
+package neo
+
+import (
+	"context"
+	"sync"
+)
+
+type bookmarksKey struct{}
+
+type bookmarks struct {
+	mu      sync.Mutex
+	initial []string
+	last    string
+}
+
+// WithBookmarks returns a context which makes the reads executed with it wait until the Neo4j server they are
+// routed to has caught up with the given bookmarks, so that the results are consistent with the writes that
+// produced them. The bookmark of the last read executed with the context is available from LastBookmark.
+func WithBookmarks(ctx context.Context, initial []string) context.Context {
+	return context.WithValue(ctx, bookmarksKey{}, &bookmarks{initial: initial})
+}
+
+// LastBookmark returns the bookmark of the last read executed with a context returned by WithBookmarks,
+// or an empty string if there is none.
+func LastBookmark(ctx context.Context) string {
+	b, ok := ctx.Value(bookmarksKey{}).(*bookmarks)
+	if !ok {
+		return ""
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.last
+}
+
+// Bookmarks returns the bookmarks set in the context with WithBookmarks.
+func Bookmarks(ctx context.Context) []string {
+	b, ok := ctx.Value(bookmarksKey{}).(*bookmarks)
+	if !ok {
+		return nil
+	}
+	return b.initial
+}
+
+func setLastBookmark(ctx context.Context, bookmark string) {
+	b, ok := ctx.Value(bookmarksKey{}).(*bookmarks)
+	if !ok || bookmark == "" {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.last = bookmark
+}
@@ -0,0 +1,29 @@
+package neo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookmarks(t *testing.T) {
+	ctx := WithBookmarks(context.Background(), []string{"FB:kcwQy5wl3dMdQ4eRk1ErFBThOpA="})
+
+	assert.Equal(t, []string{"FB:kcwQy5wl3dMdQ4eRk1ErFBThOpA="}, Bookmarks(ctx))
+	assert.Empty(t, LastBookmark(ctx))
+
+	setLastBookmark(ctx, "FB:kcwQy5wl3dMdQ4eRk1ErFBThOpA=kA")
+	assert.Equal(t, "FB:kcwQy5wl3dMdQ4eRk1ErFBThOpA=kA", LastBookmark(ctx))
+
+	setLastBookmark(ctx, "")
+	assert.Equal(t, "FB:kcwQy5wl3dMdQ4eRk1ErFBThOpA=kA", LastBookmark(ctx))
+}
+
+func TestBookmarksWithoutBookmarksContext(t *testing.T) {
+	ctx := context.Background()
+
+	setLastBookmark(ctx, "FB:kcwQy5wl3dMdQ4eRk1ErFBThOpA=kA")
+	assert.Nil(t, Bookmarks(ctx))
+	assert.Empty(t, LastBookmark(ctx))
+}
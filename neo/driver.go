@@ -0,0 +1,265 @@
+package neo
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	logger "github.com/Financial-Times/go-logger/v2"
+	"github.com/Financial-Times/neo4j-metric-aggregator/tracing"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Financial-Times/neo4j-metric-aggregator/neo")
+
+// ErrNoResultsFound is returned by Read when a query returns no records.
+var ErrNoResultsFound = errors.New("no results found")
+
+// Query is a Cypher query with its parameters. When Result is set, the first record returned by the query is
+// decoded into it, using the JSON tags of Result to match the returned keys.
+type Query struct {
+	Cypher string
+	Params map[string]interface{}
+	Result interface{}
+	// Profile runs the query with PROFILE, setting ProfileSummary once executed.
+	Profile bool
+
+	// Duration is the time the query took to run and to return its records, set once executed.
+	Duration time.Duration
+	// ProfileSummary is set once executed when Profile is set.
+	ProfileSummary *ProfileSummary
+}
+
+// Config holds the settings used to connect to Neo4j and to tune the driver.
+type Config struct {
+	MaxConnectionPoolSize        int
+	ConnectionAcquisitionTimeout time.Duration
+	// MaxTransactionRetryTime is the time the transactions are retried for on transient errors. The transactions
+	// are not retried when zero.
+	MaxTransactionRetryTime time.Duration
+	FetchSize               int
+	// TransactionTimeout is the time after which Neo4j terminates the transactions. The server default timeout
+	// is used when zero.
+	TransactionTimeout time.Duration
+	// DatabaseName is the database the queries are executed on. The server default database is used when empty.
+	DatabaseName string
+	// Username and Password are used for basic authentication. No authentication is used when Username is empty.
+	Username string
+	Password string
+	// RootCAs are the certificate authorities trusted for the TLS connections established with the bolt+s and
+	// neo4j+s URI schemes. The system certificates are used when it is nil.
+	RootCAs *x509.CertPool
+}
+
+// DefaultConfig returns the default settings of the underlying neo4j-go-driver.
+func DefaultConfig() Config {
+	return Config{
+		MaxConnectionPoolSize:        100,
+		ConnectionAcquisitionTimeout: 1 * time.Minute,
+		MaxTransactionRetryTime:      30 * time.Second,
+		FetchSize:                    neo4j.FetchDefault,
+	}
+}
+
+// Driver executes queries against Neo4j. Every call to Read and Write runs all the given queries in a single
+// transaction, which is retried on transient errors for up to MaxTransactionRetryTime. With the neo4j:// URI
+// scheme the reads are routed to the read replicas and followers of a causal cluster.
+type Driver struct {
+	driver       neo4j.Driver
+	fetchSize    int
+	txTimeout    time.Duration
+	databaseName string
+	retries      bool
+}
+
+// NewDriver creates a driver for the given URI. The connection is encrypted when the URI scheme is bolt+s or
+// neo4j+s, or bolt+ssc or neo4j+ssc for self-signed certificates.
+func NewDriver(uri string, log *logger.UPPLogger, config Config) (*Driver, error) {
+	if config.RootCAs != nil && !isVerifiedTLSURI(uri) {
+		return nil, errors.New("trusted certificate authorities are only used with the bolt+s and neo4j+s URI schemes")
+	}
+
+	auth := neo4j.NoAuth()
+	if config.Username != "" {
+		auth = neo4j.BasicAuth(config.Username, config.Password, "")
+	}
+
+	driver, err := neo4j.NewDriver(uri, auth, func(c *neo4j.Config) {
+		c.MaxConnectionPoolSize = config.MaxConnectionPoolSize
+		c.ConnectionAcquisitionTimeout = config.ConnectionAcquisitionTimeout
+		c.MaxTransactionRetryTime = config.MaxTransactionRetryTime
+		c.RootCAs = config.RootCAs
+		c.Log = &driverLogger{log}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed creating neo4j driver: %w", err)
+	}
+
+	return &Driver{
+		driver:       driver,
+		fetchSize:    config.FetchSize,
+		txTimeout:    config.TransactionTimeout,
+		databaseName: config.DatabaseName,
+		retries:      config.MaxTransactionRetryTime > 0,
+	}, nil
+}
+
+// Read executes the queries in a read transaction. It uses the bookmarks set in the context with WithBookmarks.
+// The transaction timeout is shortened to the deadline of the context, if any, and ErrTimeout is returned when
+// either of them is exceeded. Every call is traced in a neo4j.read span.
+//
+// The driver neither waits for a connection nor retries the transaction with the context, so Read returns
+// ErrTimeout as soon as the context is done and leaves the transaction to complete or time out in the
+// background. The queries must not be used anymore once ErrTimeout is returned.
+func (d *Driver) Read(ctx context.Context, queries ...*Query) (err error) {
+	ctx, span := tracer.Start(ctx, "neo4j.read",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNeo4j,
+			semconv.DBName(d.databaseName),
+			attribute.Int("db.neo4j.query_count", len(queries)),
+		))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	timeout, err := transactionTimeout(ctx, d.txTimeout)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan readResult, 1)
+	go func() {
+		done <- d.read(Bookmarks(ctx), timeout, queries)
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return classifyError(ctx, res.err)
+		}
+		setLastBookmark(ctx, res.bookmark)
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %v", ErrTimeout, ctx.Err())
+	}
+}
+
+type readResult struct {
+	bookmark string
+	err      error
+}
+
+func (d *Driver) read(bookmarks []string, timeout time.Duration, queries []*Query) readResult {
+	session := d.driver.NewSession(neo4j.SessionConfig{
+		AccessMode:   neo4j.AccessModeRead,
+		Bookmarks:    bookmarks,
+		DatabaseName: d.databaseName,
+		FetchSize:    d.fetchSize,
+	})
+	defer session.Close()
+
+	if err := d.execute(session, neo4j.AccessModeRead, timeout, queries); err != nil {
+		return readResult{err: err}
+	}
+	return readResult{bookmark: session.LastBookmark()}
+}
+
+func (d *Driver) Write(queries ...*Query) error {
+	session := d.driver.NewSession(neo4j.SessionConfig{
+		AccessMode:   neo4j.AccessModeWrite,
+		DatabaseName: d.databaseName,
+		FetchSize:    d.fetchSize,
+	})
+	defer session.Close()
+
+	if err := d.execute(session, neo4j.AccessModeWrite, d.txTimeout, queries); err != nil {
+		return classifyError(context.Background(), err)
+	}
+	return nil
+}
+
+// execute runs the queries in a transaction of the session. The driver retries the transactions it manages at
+// least once whatever MaxTransactionRetryTime, so an explicit transaction is used when the retries are disabled.
+func (d *Driver) execute(session neo4j.Session, mode neo4j.AccessMode, timeout time.Duration, queries []*Query) error {
+	if d.retries {
+		work := func(tx neo4j.Transaction) (interface{}, error) {
+			return nil, runQueries(tx, queries)
+		}
+		var err error
+		if mode == neo4j.AccessModeRead {
+			_, err = session.ReadTransaction(work, neo4j.WithTxTimeout(timeout))
+		} else {
+			_, err = session.WriteTransaction(work, neo4j.WithTxTimeout(timeout))
+		}
+		return err
+	}
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(timeout))
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	if err = runQueries(tx, queries); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *Driver) VerifyConnectivity() error {
+	return d.driver.VerifyConnectivity()
+}
+
+func (d *Driver) Close() error {
+	return d.driver.Close()
+}
+
+func runQueries(tx neo4j.Transaction, queries []*Query) error {
+	for _, q := range queries {
+		if err := runQuery(tx, q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runQuery(tx neo4j.Transaction, q *Query) error {
+	cypher := q.Cypher
+	if q.Profile {
+		cypher = "PROFILE " + cypher
+	}
+
+	start := time.Now()
+	res, err := tx.Run(cypher, q.Params)
+	if err != nil {
+		return err
+	}
+
+	if q.Result != nil {
+		if !res.Next() {
+			if err = res.Err(); err != nil {
+				return err
+			}
+			return ErrNoResultsFound
+		}
+		record := res.Record()
+		if err = decodeRecord(record.Keys, record.Values, q.Result); err != nil {
+			return fmt.Errorf("failed decoding query result: %w", err)
+		}
+	}
+
+	summary, err := res.Consume()
+	if err != nil {
+		return err
+	}
+	q.Duration = time.Since(start)
+	if q.Profile && summary.Profile() != nil {
+		q.ProfileSummary = newProfileSummary(summary.Profile())
+	}
+	return nil
+}
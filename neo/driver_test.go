@@ -0,0 +1,170 @@
+// +build integration
+
+package neo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logger "github.com/Financial-Times/go-logger/v2"
+)
+
+func TestDriverWriteAndRead(t *testing.T) {
+	d := newTestDriver(t)
+	defer cleanDB(t, d)
+
+	conceptUUID := uuid.New().String()
+	err := d.Write(&Query{
+		Cypher: "CREATE (:Concept{prefUUID: $uuid, prefLabel: $prefLabel})",
+		Params: map[string]interface{}{"uuid": conceptUUID, "prefLabel": "Jane Doe"},
+	})
+	require.NoError(t, err)
+
+	result := struct {
+		UUID      string `json:"uuid"`
+		PrefLabel string `json:"prefLabel"`
+	}{}
+	err = d.Read(context.Background(), &Query{
+		Cypher: "MATCH (c:Concept{prefUUID: $uuid}) RETURN c.prefUUID AS uuid, c.prefLabel AS prefLabel",
+		Params: map[string]interface{}{"uuid": conceptUUID},
+		Result: &result,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, conceptUUID, result.UUID)
+	assert.Equal(t, "Jane Doe", result.PrefLabel)
+}
+
+func TestDriverReadNoResults(t *testing.T) {
+	d := newTestDriver(t)
+
+	result := struct {
+		UUID string `json:"uuid"`
+	}{}
+	err := d.Read(context.Background(), &Query{
+		Cypher: "MATCH (c:Concept{prefUUID: $uuid}) RETURN c.prefUUID AS uuid",
+		Params: map[string]interface{}{"uuid": uuid.New().String()},
+		Result: &result,
+	})
+	assert.Equal(t, ErrNoResultsFound, err)
+}
+
+func TestDriverReadWithBookmarks(t *testing.T) {
+	d := newTestDriver(t)
+
+	result := struct {
+		One int64 `json:"one"`
+	}{}
+	ctx := WithBookmarks(context.Background(), nil)
+	err := d.Read(ctx, &Query{Cypher: "RETURN 1 AS one", Result: &result})
+	require.NoError(t, err)
+
+	bookmark := LastBookmark(ctx)
+	assert.NotEmpty(t, bookmark)
+
+	ctx = WithBookmarks(context.Background(), []string{bookmark})
+	err = d.Read(ctx, &Query{Cypher: "RETURN 1 AS one", Result: &result})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, LastBookmark(ctx))
+}
+
+func TestDriverConnectionError(t *testing.T) {
+	log := logger.NewUPPLogger("test-neo4j-metric-aggregator", "warning")
+	config := DefaultConfig()
+	config.MaxTransactionRetryTime = 0
+	d, err := NewDriver("bolt://localhost:80", log, config)
+	require.NoError(t, err)
+
+	assert.Error(t, d.VerifyConnectivity())
+	err = d.Read(context.Background(), &Query{Cypher: "RETURN 1 AS one", Result: &struct{}{}})
+	assert.True(t, errors.Is(err, ErrUnavailable))
+}
+
+func TestDriverBasicAuth(t *testing.T) {
+	url, username, password := getNeoAuthTestSettings(t)
+	log := logger.NewUPPLogger("test-neo4j-metric-aggregator", "warning")
+
+	config := DefaultConfig()
+	config.Username = username
+	config.Password = password
+	d, err := NewDriver(url, log, config)
+	require.NoError(t, err)
+	defer d.Close()
+
+	assert.NoError(t, d.VerifyConnectivity())
+
+	result := struct {
+		One int64 `json:"one"`
+	}{}
+	err = d.Read(context.Background(), &Query{Cypher: "RETURN 1 AS one", Result: &result})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), result.One)
+}
+
+func TestDriverBasicAuthWrongCredentials(t *testing.T) {
+	url, username, _ := getNeoAuthTestSettings(t)
+	log := logger.NewUPPLogger("test-neo4j-metric-aggregator", "warning")
+
+	config := DefaultConfig()
+	config.Username = username
+	config.Password = "wrong-password"
+	config.MaxTransactionRetryTime = 0
+	d, err := NewDriver(url, log, config)
+	require.NoError(t, err)
+	defer d.Close()
+
+	assert.Error(t, d.VerifyConnectivity())
+}
+
+func TestDriverWithoutCredentialsOnAuthEnabledNeo4j(t *testing.T) {
+	url, _, _ := getNeoAuthTestSettings(t)
+	log := logger.NewUPPLogger("test-neo4j-metric-aggregator", "warning")
+
+	config := DefaultConfig()
+	config.MaxTransactionRetryTime = 0
+	d, err := NewDriver(url, log, config)
+	require.NoError(t, err)
+	defer d.Close()
+
+	assert.Error(t, d.VerifyConnectivity())
+}
+
+// getNeoAuthTestSettings returns the settings of a Neo4j instance with authentication enabled, such as the
+// neo4j-auth service in docker-compose-tests.yml.
+func getNeoAuthTestSettings(t *testing.T) (url string, username string, password string) {
+	if testing.Short() {
+		t.Skip("Skipping Neo4j integration tests.")
+	}
+
+	url = os.Getenv("NEO4J_AUTH_TEST_URL")
+	if url == "" {
+		t.Skip("Skipping Neo4j authentication tests, NEO4J_AUTH_TEST_URL is not set.")
+	}
+	return url, os.Getenv("NEO4J_AUTH_TEST_USERNAME"), os.Getenv("NEO4J_AUTH_TEST_PASSWORD")
+}
+
+func newTestDriver(t *testing.T) *Driver {
+	if testing.Short() {
+		t.Skip("Skipping Neo4j integration tests.")
+	}
+
+	url := os.Getenv("NEO4J_TEST_URL")
+	if url == "" {
+		url = "bolt://localhost:7687"
+	}
+
+	log := logger.NewUPPLogger("test-neo4j-metric-aggregator", "warning")
+	d, err := NewDriver(url, log, DefaultConfig())
+	require.NoError(t, err)
+	return d
+}
+
+func cleanDB(t *testing.T, d *Driver) {
+	err := d.Write(&Query{Cypher: "MATCH (n:Concept) DETACH DELETE n"})
+	require.NoError(t, err)
+}
@@ -0,0 +1,41 @@
+package neo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+var (
+	// ErrTimeout is returned by Read and Write when a transaction is terminated by Neo4j for exceeding its
+	// timeout, or when the deadline of the context expires.
+	ErrTimeout = errors.New("neo4j transaction timed out")
+	// ErrUnavailable is returned by Read and Write when Neo4j cannot be reached or fails with a transient error,
+	// such as an expired session or a leader switch during a cluster failover, which may succeed when retried.
+	ErrUnavailable = errors.New("neo4j unavailable")
+	// ErrRetriesExhausted is returned by Read and Write, along with ErrUnavailable, when a transaction kept
+	// failing with transient errors for MaxTransactionRetryTime. It is not worth retrying again.
+	ErrRetriesExhausted = errors.New("neo4j transaction retries exhausted")
+)
+
+const transactionTimedOutCode = "Neo.ClientError.Transaction.TransactionTimedOut"
+
+// classifyError marks err with ErrTimeout or ErrUnavailable when it is caused by a timeout or is transient, and
+// with ErrRetriesExhausted when the driver gave up retrying it. Any other error is permanent.
+func classifyError(ctx context.Context, err error) error {
+	var neoErr *neo4j.Neo4jError
+	isNeoErr := errors.As(err, &neoErr)
+
+	switch {
+	case isNeoErr && neoErr.Code == transactionTimedOutCode, errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%w: %v", ErrTimeout, err)
+	case neo4j.IsTransactionExecutionLimit(err):
+		return fmt.Errorf("%w: %w: %v", ErrUnavailable, ErrRetriesExhausted, err)
+	case isNeoErr && (neoErr.Classification() == "TransientError" || neoErr.IsRetriableCluster()),
+		neo4j.IsConnectivityError(err):
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return err
+}
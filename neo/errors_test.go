@@ -0,0 +1,72 @@
+package neo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	expired, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	tests := map[string]struct {
+		ctx           context.Context
+		err           error
+		isTimeout     bool
+		isUnavailable bool
+		isExhausted   bool
+	}{
+		"transaction timed out": {
+			ctx:       context.Background(),
+			err:       &neo4j.Neo4jError{Code: "Neo.ClientError.Transaction.TransactionTimedOut"},
+			isTimeout: true,
+		},
+		"context deadline exceeded": {
+			ctx:       expired,
+			err:       errors.New("connection closed"),
+			isTimeout: true,
+		},
+		"transient neo4j error": {
+			ctx:           context.Background(),
+			err:           &neo4j.Neo4jError{Code: "Neo.TransientError.General.DatabaseUnavailable"},
+			isUnavailable: true,
+		},
+		"leader switch": {
+			ctx:           context.Background(),
+			err:           &neo4j.Neo4jError{Code: "Neo.ClientError.Cluster.NotALeader"},
+			isUnavailable: true,
+		},
+		"transaction retries exhausted": {
+			ctx: context.Background(),
+			err: &neo4j.TransactionExecutionLimit{
+				Errors: []error{&neo4j.Neo4jError{Code: "Neo.TransientError.Transaction.LockClientStopped"}},
+				Causes: []string{"Timeout after 30s"},
+			},
+			isUnavailable: true,
+			isExhausted:   true,
+		},
+		"other neo4j error": {
+			ctx: context.Background(),
+			err: &neo4j.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError"},
+		},
+		"other error": {
+			ctx: context.Background(),
+			err: errors.New("connection closed"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := classifyError(test.ctx, test.err)
+			assert.Equal(t, test.isTimeout, errors.Is(err, ErrTimeout))
+			assert.Equal(t, test.isUnavailable, errors.Is(err, ErrUnavailable))
+			assert.Equal(t, test.isExhausted, errors.Is(err, ErrRetriesExhausted))
+			assert.Contains(t, err.Error(), test.err.Error())
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package neo
+
+import (
+	logger "github.com/Financial-Times/go-logger/v2"
+)
+
+// driverLogger forwards the neo4j-go-driver logs to the UPP logger.
+type driverLogger struct {
+	log *logger.UPPLogger
+}
+
+func (l *driverLogger) Error(name string, id string, err error) {
+	l.log.WithField("component", name).WithField("componentID", id).WithError(err).Error("neo4j driver error")
+}
+
+func (l *driverLogger) Warnf(name string, id string, msg string, args ...interface{}) {
+	l.log.WithField("component", name).WithField("componentID", id).Warnf(msg, args...)
+}
+
+func (l *driverLogger) Infof(name string, id string, msg string, args ...interface{}) {
+	l.log.WithField("component", name).WithField("componentID", id).Infof(msg, args...)
+}
+
+func (l *driverLogger) Debugf(name string, id string, msg string, args ...interface{}) {
+	l.log.WithField("component", name).WithField("componentID", id).Debugf(msg, args...)
+}
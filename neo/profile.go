@@ -0,0 +1,23 @@
+package neo
+
+import "github.com/neo4j/neo4j-go-driver/v4/neo4j"
+
+// ProfileSummary sums up the profiled execution plan of a query.
+type ProfileSummary struct {
+	// DBHits is the number of times the whole plan touched the database.
+	DBHits int64
+	// Rows is the number of records returned by the query.
+	Rows int64
+}
+
+func newProfileSummary(plan neo4j.ProfiledPlan) *ProfileSummary {
+	return &ProfileSummary{DBHits: totalDBHits(plan), Rows: plan.Records()}
+}
+
+func totalDBHits(plan neo4j.ProfiledPlan) int64 {
+	hits := plan.DbHits()
+	for _, child := range plan.Children() {
+		hits += totalDBHits(child)
+	}
+	return hits
+}
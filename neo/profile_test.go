@@ -0,0 +1,36 @@
+package neo
+
+import (
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProfileSummary(t *testing.T) {
+	plan := &testProfiledPlan{
+		dbHits:  2,
+		records: 1,
+		children: []neo4j.ProfiledPlan{
+			&testProfiledPlan{dbHits: 10, records: 5},
+			&testProfiledPlan{dbHits: 3, records: 5, children: []neo4j.ProfiledPlan{
+				&testProfiledPlan{dbHits: 7, records: 20},
+			}},
+		},
+	}
+
+	assert.Equal(t, &ProfileSummary{DBHits: 22, Rows: 1}, newProfileSummary(plan))
+}
+
+type testProfiledPlan struct {
+	dbHits   int64
+	records  int64
+	children []neo4j.ProfiledPlan
+}
+
+func (p *testProfiledPlan) Operator() string                  { return "" }
+func (p *testProfiledPlan) Arguments() map[string]interface{} { return nil }
+func (p *testProfiledPlan) Identifiers() []string             { return nil }
+func (p *testProfiledPlan) DbHits() int64                     { return p.dbHits }
+func (p *testProfiledPlan) Records() int64                    { return p.records }
+func (p *testProfiledPlan) Children() []neo4j.ProfiledPlan    { return p.children }
@@ -0,0 +1,17 @@
+package neo
+
+import "encoding/json"
+
+// decodeRecord decodes the record values into result, matching the record keys against the JSON tags of result.
+func decodeRecord(keys []string, values []interface{}, result interface{}) error {
+	fields := make(map[string]interface{}, len(keys))
+	for i, key := range keys {
+		fields[key] = values[i]
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
@@ -0,0 +1,55 @@
+package neo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testResult struct {
+	UUID   string   `json:"uuid"`
+	Count  int64    `json:"count"`
+	Epoch  *int64   `json:"epoch"`
+	Labels []string `json:"labels"`
+}
+
+func TestDecodeRecord(t *testing.T) {
+	epoch := int64(1633046400)
+
+	tests := map[string]struct {
+		keys           []string
+		values         []interface{}
+		expectedResult testResult
+	}{
+		"all values": {
+			keys:           []string{"uuid", "count", "epoch", "labels"},
+			values:         []interface{}{"601a5957-74ab-4eab-8a43-4596355c9420", int64(12), epoch, []interface{}{"Concept", "Person"}},
+			expectedResult: testResult{UUID: "601a5957-74ab-4eab-8a43-4596355c9420", Count: 12, Epoch: &epoch, Labels: []string{"Concept", "Person"}},
+		},
+		"null values": {
+			keys:           []string{"uuid", "count", "epoch"},
+			values:         []interface{}{"601a5957-74ab-4eab-8a43-4596355c9420", int64(0), nil},
+			expectedResult: testResult{UUID: "601a5957-74ab-4eab-8a43-4596355c9420"},
+		},
+		"unknown keys": {
+			keys:           []string{"uuid", "somethingElse"},
+			values:         []interface{}{"601a5957-74ab-4eab-8a43-4596355c9420", "ignored"},
+			expectedResult: testResult{UUID: "601a5957-74ab-4eab-8a43-4596355c9420"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var result testResult
+			err := decodeRecord(test.keys, test.values, &result)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedResult, result)
+		})
+	}
+}
+
+func TestDecodeRecordTypeMismatch(t *testing.T) {
+	var result testResult
+	err := decodeRecord([]string{"count"}, []interface{}{"twelve"}, &result)
+	assert.Error(t, err)
+}
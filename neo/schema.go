@@ -0,0 +1,112 @@
+package neo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// findIndexQuery returns the states, such as ONLINE or POPULATING, of the indexes on the property of the nodes
+// with the label, including the index backing a uniqueness constraint. The states are empty when no such index
+// exists.
+const findIndexQuery = `
+	SHOW INDEXES YIELD labelsOrTypes, properties, state
+	WHERE labelsOrTypes = [$label] AND properties = [$property]
+	RETURN collect(state) AS states
+`
+
+const indexOnline = "ONLINE"
+
+// identifierPattern matches the labels and properties which can be safely used in the schema commands, as they
+// cannot be given as parameters.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Index is a single property index on the nodes with a label.
+type Index struct {
+	Label    string
+	Property string
+}
+
+func (i Index) String() string {
+	return fmt.Sprintf(":%s(%s)", i.Label, i.Property)
+}
+
+// MissingIndexes returns the given indexes which do not exist or are not online yet, such as the indexes still
+// being populated.
+func (d *Driver) MissingIndexes(ctx context.Context, indexes []Index) ([]Index, error) {
+	var missing []Index
+	for _, index := range indexes {
+		states, err := d.indexStates(ctx, index)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(states, indexOnline) {
+			missing = append(missing, index)
+		}
+	}
+	return missing, nil
+}
+
+// EnsureIndexes creates the given indexes which do not exist yet, and waits for up to timeout for all of them to
+// be online, including the existing ones still being populated. The existing indexes, including the indexes backing
+// uniqueness constraints, are left untouched. It returns the created indexes, and fails when any of the indexes is
+// not online in time.
+func (d *Driver) EnsureIndexes(ctx context.Context, indexes []Index, timeout time.Duration) ([]Index, error) {
+	var created, pending []Index
+	for _, index := range indexes {
+		states, err := d.indexStates(ctx, index)
+		if err != nil {
+			return created, err
+		}
+		if slices.Contains(states, indexOnline) {
+			continue
+		}
+		if len(states) > 0 {
+			pending = append(pending, index)
+			continue
+		}
+
+		if !identifierPattern.MatchString(index.Label) || !identifierPattern.MatchString(index.Property) {
+			return created, fmt.Errorf("invalid index %v", index)
+		}
+		err = d.Write(&Query{Cypher: fmt.Sprintf("CREATE INDEX IF NOT EXISTS FOR (n:%s) ON (n.%s)", index.Label, index.Property)})
+		if err != nil {
+			return created, fmt.Errorf("failed creating index %v: %w", index, err)
+		}
+		created = append(created, index)
+	}
+
+	if len(created) == 0 && len(pending) == 0 {
+		return created, nil
+	}
+	err := d.Write(&Query{Cypher: "CALL db.awaitIndexes($timeout)", Params: map[string]interface{}{"timeout": int64(timeout.Seconds())}})
+	if err != nil {
+		return created, fmt.Errorf("failed waiting for the indexes to be online: %w", err)
+	}
+	missing, err := d.MissingIndexes(ctx, append(created, pending...))
+	if err != nil {
+		return created, err
+	}
+	if len(missing) > 0 {
+		return created, fmt.Errorf("indexes %v are not online after %v", missing, timeout)
+	}
+	return created, nil
+}
+
+// indexStates returns the states of the indexes matching the given one, empty when there is none.
+func (d *Driver) indexStates(ctx context.Context, index Index) ([]string, error) {
+	var res struct {
+		States []string `json:"states"`
+	}
+	err := d.Read(ctx, &Query{
+		Cypher: findIndexQuery,
+		Params: map[string]interface{}{"label": index.Label, "property": index.Property},
+		Result: &res,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed looking up index %v: %w", index, err)
+	}
+	return res.States, nil
+}
@@ -0,0 +1,103 @@
+// +build integration
+
+package neo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureIndexes(t *testing.T) {
+	d := newTestDriver(t)
+
+	label := "SchemaTest" + strings.ReplaceAll(uuid.New().String(), "-", "")
+	indexes := []Index{
+		{Label: label, Property: "prefUUID"},
+		{Label: label, Property: "publishedDateEpoch"},
+	}
+	defer dropTestIndexes(t, d, label)
+
+	missing, err := d.MissingIndexes(context.Background(), indexes)
+	require.NoError(t, err)
+	assert.Equal(t, indexes, missing)
+
+	created, err := d.EnsureIndexes(context.Background(), indexes, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, indexes, created)
+
+	missing, err = d.MissingIndexes(context.Background(), indexes)
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	created, err = d.EnsureIndexes(context.Background(), indexes, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, created, "the existing indexes must not be created again")
+}
+
+func TestEnsureIndexesWithConstraint(t *testing.T) {
+	d := newTestDriver(t)
+
+	label := "SchemaTest" + strings.ReplaceAll(uuid.New().String(), "-", "")
+	err := d.Write(&Query{Cypher: fmt.Sprintf("CREATE CONSTRAINT %s_uuid ON (n:%s) ASSERT n.uuid IS UNIQUE", label, label)})
+	require.NoError(t, err)
+	defer func() {
+		err := d.Write(&Query{Cypher: fmt.Sprintf("DROP CONSTRAINT %s_uuid", label)})
+		assert.NoError(t, err)
+	}()
+
+	created, err := d.EnsureIndexes(context.Background(), []Index{{Label: label, Property: "uuid"}}, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, created, "the index backing the constraint must be used")
+}
+
+func TestEnsureIndexesWaitsForExistingIndexes(t *testing.T) {
+	d := newTestDriver(t)
+
+	label := "SchemaTest" + strings.ReplaceAll(uuid.New().String(), "-", "")
+	indexes := []Index{{Label: label, Property: "prefUUID"}}
+	defer dropTestIndexes(t, d, label)
+
+	// the index may still be populating when EnsureIndexes looks it up
+	err := d.Write(&Query{Cypher: fmt.Sprintf("CREATE INDEX FOR (n:%s) ON (n.prefUUID)", label)})
+	require.NoError(t, err)
+
+	created, err := d.EnsureIndexes(context.Background(), indexes, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, created, "the existing index must not be reported as created")
+
+	missing, err := d.MissingIndexes(context.Background(), indexes)
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+}
+
+func TestEnsureIndexesInvalidLabel(t *testing.T) {
+	d := newTestDriver(t)
+
+	_, err := d.EnsureIndexes(context.Background(), []Index{{Label: "Concept) DETACH DELETE (n", Property: "uuid"}}, time.Minute)
+	assert.EqualError(t, err, "invalid index :Concept) DETACH DELETE (n(uuid)")
+}
+
+func dropTestIndexes(t *testing.T, d *Driver, label string) {
+	for {
+		var res struct {
+			Name string `json:"name"`
+		}
+		err := d.Read(context.Background(), &Query{
+			Cypher: "SHOW INDEXES YIELD name, labelsOrTypes WHERE labelsOrTypes = [$label] RETURN name",
+			Params: map[string]interface{}{"label": label},
+			Result: &res,
+		})
+		if err == ErrNoResultsFound {
+			return
+		}
+		require.NoError(t, err)
+		require.NoError(t, d.Write(&Query{Cypher: fmt.Sprintf("DROP INDEX %s", res.Name)}))
+	}
+}
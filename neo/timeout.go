@@ -0,0 +1,27 @@
+package neo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// transactionTimeout returns the timeout of a transaction started with ctx: the configured timeout, shortened
+// to the time left until the deadline of the context. Zero means that the server default timeout is used.
+func transactionTimeout(ctx context.Context, configured time.Duration) (time.Duration, error) {
+	timeout := configured
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return timeout, nil
+	}
+
+	left := time.Until(deadline)
+	// Neo4j takes the timeout in milliseconds and ignores anything shorter.
+	if left < time.Millisecond {
+		return 0, fmt.Errorf("%w: %v", ErrTimeout, context.DeadlineExceeded)
+	}
+	if timeout == 0 || left < timeout {
+		timeout = left
+	}
+	return timeout, nil
+}
@@ -0,0 +1,120 @@
+package neo
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logger "github.com/Financial-Times/go-logger/v2"
+)
+
+func TestTransactionTimeout(t *testing.T) {
+	tests := map[string]struct {
+		configured  time.Duration
+		budget      time.Duration
+		expectedMin time.Duration
+		expectedMax time.Duration
+	}{
+		"no deadline and no configured timeout": {
+			expectedMin: 0,
+			expectedMax: 0,
+		},
+		"no deadline": {
+			configured:  5 * time.Second,
+			expectedMin: 5 * time.Second,
+			expectedMax: 5 * time.Second,
+		},
+		"deadline after the configured timeout": {
+			configured:  5 * time.Second,
+			budget:      time.Minute,
+			expectedMin: 5 * time.Second,
+			expectedMax: 5 * time.Second,
+		},
+		"deadline before the configured timeout": {
+			configured:  5 * time.Second,
+			budget:      2 * time.Second,
+			expectedMin: time.Second,
+			expectedMax: 2 * time.Second,
+		},
+		"deadline without configured timeout": {
+			budget:      2 * time.Second,
+			expectedMin: time.Second,
+			expectedMax: 2 * time.Second,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.budget > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.budget)
+				defer cancel()
+			}
+
+			timeout, err := transactionTimeout(ctx, test.configured)
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, int64(timeout), int64(test.expectedMin))
+			assert.LessOrEqual(t, int64(timeout), int64(test.expectedMax))
+		})
+	}
+}
+
+func TestTransactionTimeoutExpiredDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	_, err := transactionTimeout(ctx, 5*time.Second)
+	assert.True(t, errors.Is(err, ErrTimeout))
+}
+
+func TestReadStopsAtDeadline(t *testing.T) {
+	// a database which accepts the connections and never answers, with the driver retrying for 30s
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	d, err := NewDriver("bolt://"+listener.Addr().String(), logger.NewUPPLogger("test-neo4j-metric-aggregator", "panic"), DefaultConfig())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = d.Read(ctx, &Query{Cypher: "RETURN 1"})
+	assert.True(t, errors.Is(err, ErrTimeout), err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestReadWithoutRetries(t *testing.T) {
+	// a database which refuses the connections, which the driver would retry for 30s
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	config := DefaultConfig()
+	config.MaxTransactionRetryTime = 0
+	d, err := NewDriver("bolt://"+addr, logger.NewUPPLogger("test-neo4j-metric-aggregator", "panic"), config)
+	require.NoError(t, err)
+
+	start := time.Now()
+	err = d.Read(context.Background(), &Query{Cypher: "RETURN 1"})
+	assert.True(t, errors.Is(err, ErrUnavailable), err)
+	assert.False(t, errors.Is(err, ErrRetriesExhausted), err)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,31 @@
+package neo
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// LoadCABundle reads the PEM encoded certificate authorities from the file at the given path.
+func LoadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading CA bundle: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM encoded certificates found in CA bundle %v", path)
+	}
+	return pool, nil
+}
+
+func isVerifiedTLSURI(uri string) bool {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	return strings.HasSuffix(parsed.Scheme, "+s")
+}
@@ -0,0 +1,91 @@
+package neo
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logger "github.com/Financial-Times/go-logger/v2"
+)
+
+func TestLoadCABundle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(path, newTestCACertificatePEM(t), 0600))
+
+	pool, err := LoadCABundle(path)
+	assert.NoError(t, err)
+	assert.NotNil(t, pool)
+}
+
+func TestLoadCABundleWithoutCertificates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0600))
+
+	_, err := LoadCABundle(path)
+	assert.Error(t, err)
+}
+
+func TestLoadMissingCABundle(t *testing.T) {
+	_, err := LoadCABundle(filepath.Join(t.TempDir(), "missing.pem"))
+	assert.Error(t, err)
+}
+
+func TestIsVerifiedTLSURI(t *testing.T) {
+	tests := map[string]bool{
+		"bolt://localhost:7687":      false,
+		"neo4j://localhost:7687":     false,
+		"bolt+ssc://localhost:7687":  false,
+		"neo4j+ssc://localhost:7687": false,
+		"bolt+s://localhost:7687":    true,
+		"neo4j+s://localhost:7687":   true,
+	}
+
+	for uri, expected := range tests {
+		t.Run(uri, func(t *testing.T) {
+			assert.Equal(t, expected, isVerifiedTLSURI(uri))
+		})
+	}
+}
+
+func TestNewDriverRejectsCABundleWithoutTLS(t *testing.T) {
+	log := logger.NewUPPLogger("test-neo4j-metric-aggregator", "warning")
+	config := DefaultConfig()
+	config.RootCAs = x509.NewCertPool()
+
+	_, err := NewDriver("bolt://localhost:7687", log, config)
+	assert.Error(t, err)
+
+	d, err := NewDriver("neo4j+s://localhost:7687", log, config)
+	assert.NoError(t, err)
+	assert.NoError(t, d.Close())
+}
+
+func newTestCACertificatePEM(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-neo4j-metric-aggregator CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
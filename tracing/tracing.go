@@ -0,0 +1,78 @@
+// Package tracing sets up the OpenTelemetry tracing of the requests, propagated with the W3C traceparent header.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewOTLPExporter creates an exporter sending the spans over OTLP/HTTP to the collector at the given endpoint,
+// given as host:port. The connection is not encrypted when insecure is set.
+func NewOTLPExporter(ctx context.Context, endpoint string, insecure bool) (sdktrace.SpanExporter, error) {
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+	if insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating OTLP trace exporter: %w", err)
+	}
+	return exporter, nil
+}
+
+// NewStdoutExporter creates an exporter writing the spans as JSON to w, for debugging and tests.
+func NewStdoutExporter(w io.Writer) (sdktrace.SpanExporter, error) {
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		return nil, fmt.Errorf("failed creating stdout trace exporter: %w", err)
+	}
+	return exporter, nil
+}
+
+// Setup installs a tracer provider exporting the spans of the given service with exporter, sampling
+// sampleRatio of the traces started here and following the sampling decision of the incoming traceparent header
+// otherwise. The spans are not recorded when exporter is nil. The returned function flushes the spans left and
+// shuts the tracer provider down.
+func Setup(serviceName string, exporter sdktrace.SpanExporter, sampleRatio float64) func(ctx context.Context) error {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if exporter == nil {
+		return func(context.Context) error { return nil }
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown
+}
+
+// Handler wraps next, continuing the trace given in the traceparent header of the requests.
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// EndSpan records err, if any, as the status of span, and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testParentID    = "00f067aa0ba902b7"
+	testTraceparent = "00-" + testTraceID + "-" + testParentID + "-01"
+)
+
+func TestHandlerContinuesTraceparent(t *testing.T) {
+	var buf bytes.Buffer
+	exporter, err := NewStdoutExporter(&buf)
+	require.NoError(t, err)
+	shutdown := Setup("test-neo4j-metric-aggregator", exporter, 0)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := otel.Tracer("test").Start(r.Context(), "test-span")
+		EndSpan(span, errors.New("computer says no"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/concepts/metrics", nil)
+	req.Header.Set("traceparent", testTraceparent)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	require.NoError(t, shutdown(context.Background()))
+
+	exported := buf.String()
+	assert.Contains(t, exported, `"Name":"test-span"`)
+	assert.Contains(t, exported, testTraceID)
+	assert.Contains(t, exported, testParentID)
+	assert.Contains(t, exported, "computer says no")
+}
+
+func TestSetupWithoutExporter(t *testing.T) {
+	shutdown := Setup("test-neo4j-metric-aggregator", nil, 1)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	defer span.End()
+	assert.False(t, span.IsRecording())
+}